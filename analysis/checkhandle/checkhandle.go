@@ -0,0 +1,346 @@
+// Package checkhandle defines an Analyzer reporting incorrect use of the
+// panic based emulation of the Go 2 check and handle.
+//
+// check panics with the error and only a deferred handle given a pointer to
+// the named error result turns the panic back into the result. The analyzer
+// reports
+//
+//   - check calls in functions without a deferred handle, neither their own
+//     nor one of a function calling them synchronously, unless they are
+//     given the *Scope of a handle,
+//   - check calls whose scope does not match the deferred handles: the
+//     handle of a Scope only recovers the checks of that scope, s.Check0 or
+//     CheckIn(...)(s), and Handle only the checks without a scope,
+//   - check calls in goroutines, which no handle of the launching function
+//     can recover, Group.Go is a function call and forwards them instead,
+//   - handle calls that are not deferred directly, in which recover does not
+//     stop the panic,
+//   - handle given anything but a pointer to the last, named error result.
+package checkhandle
+
+import (
+	"go/ast"
+	"go/types"
+	"regexp"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const Doc = `report incorrect use of check and handle
+
+check panics with its error, so every function calling it needs
+defer handle(&err) with err being its named error result. Goroutines do not
+share the handle of the function launching them and handle only recovers
+when it is the deferred function itself.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "checkhandle",
+	Doc:  Doc,
+	Run:  run,
+}
+
+var (
+	checkPattern  = `^(check|Check\w*)$`
+	handlePattern = `^(handle|Handle\w*)$`
+	scopeType     = "github.com/jkmar/go_less_verbose_error_handling/errorhandling.Scope"
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&checkPattern, "check", checkPattern, "regular expression matching the names of check functions")
+	Analyzer.Flags.StringVar(&handlePattern, "handle", handlePattern, "regular expression matching the names of handle functions")
+	Analyzer.Flags.StringVar(&scopeType, "scope", scopeType, "package path and name of the type of the scopes of checks and handles")
+}
+
+type checker struct {
+	pass   *analysis.Pass
+	check  *regexp.Regexp
+	handle *regexp.Regexp
+	// deferred holds the handle calls made directly by defer.
+	deferred map[*ast.CallExpr]bool
+	// applied holds the checks like CheckIn(x, err) returning a function
+	// which is called right away with the scope the check belongs to.
+	applied map[*ast.CallExpr]ast.Expr
+}
+
+// recovery describes the deferred handles a function runs under: unscoped
+// for handle and scopes for the handles of the Scope variables, including
+// Scope parameters, whose handle is up to the caller.
+type recovery struct {
+	unscoped bool
+	scopes   map[types.Object]bool
+}
+
+func (r recovery) with(other recovery) recovery {
+	result := recovery{unscoped: r.unscoped || other.unscoped, scopes: make(map[types.Object]bool)}
+	for _, scopes := range []map[types.Object]bool{r.scopes, other.scopes} {
+		for scope := range scopes {
+			result.scopes[scope] = true
+		}
+	}
+	return result
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	check, err := regexp.Compile(checkPattern)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := regexp.Compile(handlePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &checker{
+		pass:     pass,
+		check:    check,
+		handle:   handle,
+		deferred: make(map[*ast.CallExpr]bool),
+		applied:  make(map[*ast.CallExpr]ast.Expr),
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			// check functions propagate the panic on purpose.
+			if fn.Recv == nil && check.MatchString(fn.Name.Name) {
+				continue
+			}
+			c.function(fn.Name.Name, fn.Type, fn.Body, recovery{}, false)
+		}
+	}
+	return nil, nil
+}
+
+// callee returns the function called by call, if it is a declared function.
+func (c *checker) callee(call *ast.CallExpr) *types.Func {
+	fun := ast.Unparen(call.Fun)
+	if index, ok := fun.(*ast.IndexExpr); ok {
+		fun = index.X
+	} else if index, ok := fun.(*ast.IndexListExpr); ok {
+		fun = index.X
+	}
+
+	var ident *ast.Ident
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil
+	}
+	f, _ := c.pass.TypesInfo.Uses[ident].(*types.Func)
+	return f
+}
+
+func (c *checker) isCheck(call *ast.CallExpr) bool {
+	f := c.callee(call)
+	if f == nil || !c.check.MatchString(f.Name()) {
+		return false
+	}
+	params := f.Type().(*types.Signature).Params()
+	return params.Len() > 0 && types.Identical(params.At(params.Len()-1).Type(), errorType)
+}
+
+func (c *checker) isHandle(call *ast.CallExpr) bool {
+	f := c.callee(call)
+	if f == nil || !c.handle.MatchString(f.Name()) {
+		return false
+	}
+	params := f.Type().(*types.Signature).Params()
+	return params.Len() > 0 && types.Identical(params.At(0).Type(), types.NewPointer(errorType))
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// function checks the body of a function. recovered holds the handles
+// deferred by the callers running it synchronously, goroutine tells whether
+// it runs in a goroutine launched by the analyzed function.
+func (c *checker) function(name string, typ *ast.FuncType, body *ast.BlockStmt, recovered recovery, goroutine bool) {
+	// Deferred handles of this function, not of nested ones.
+	handled := recovery{scopes: c.scoped(typ)}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.DeferStmt:
+			if c.isHandle(n.Call) {
+				c.deferred[n.Call] = true
+				c.handleArgument(name, typ, n.Call)
+				if scope, ok := c.scope(n.Call); ok {
+					handled.scopes[c.object(scope)] = true
+				} else {
+					handled.unscoped = true
+				}
+			}
+		}
+		return true
+	})
+	recovered = recovered.with(handled)
+
+	var parents []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			parents = parents[:len(parents)-1]
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			if launched(parents, n) {
+				c.function("function literal", n.Type, n.Body, recovery{}, true)
+				return false
+			}
+			c.function("function literal", n.Type, n.Body, recovered, goroutine)
+			return false
+		case *ast.CallExpr:
+			if inner, ok := ast.Unparen(n.Fun).(*ast.CallExpr); ok && len(n.Args) == 1 && c.isScope(c.pass.TypesInfo.TypeOf(n.Args[0])) {
+				c.applied[inner] = n.Args[0]
+			}
+			switch {
+			case c.isCheck(n):
+				c.checkCall(name, n, recovered, goroutine)
+			case c.isHandle(n) && !c.deferred[n]:
+				c.pass.Reportf(n.Pos(), "handle has to be deferred directly, recover does not stop the panic otherwise")
+			}
+		}
+
+		parents = append(parents, n)
+		return true
+	})
+}
+
+// checkCall reports a check which none of the recovered handles recovers.
+func (c *checker) checkCall(name string, call *ast.CallExpr, recovered recovery, goroutine bool) {
+	scope, scoped := c.scope(call)
+	if !scoped && c.returnsScoped(call) {
+		// The check only panics once given its scope.
+		scope, scoped = c.applied[call]
+		if !scoped {
+			return
+		}
+	}
+
+	switch {
+	case scoped:
+		object := c.object(scope)
+		if object == nil || recovered.scopes[object] {
+			return
+		}
+		scopeName := types.ExprString(scope)
+		switch {
+		case goroutine:
+			c.pass.Reportf(call.Pos(), "check in a goroutine is not recovered by the handle of the launching function, defer %s.Handle in the goroutine or start it with Group.Go", scopeName)
+		case recovered.unscoped:
+			c.pass.Reportf(call.Pos(), "check of scope %s used in %s is not recovered by handle, which only recovers checks without a scope, defer %[1]s.Handle(&err)", scopeName, name)
+		default:
+			c.pass.Reportf(call.Pos(), "check of scope %s used in %s without defer %[1]s.Handle(&err), the panic escapes to its callers", scopeName, name)
+		}
+	case recovered.unscoped:
+	case goroutine:
+		c.pass.Reportf(call.Pos(), "check in a goroutine is not recovered by the handle of the launching function, defer handle in the goroutine or start it with Group.Go")
+	case len(recovered.scopes) > 0:
+		c.pass.Reportf(call.Pos(), "check used in %s is not recovered by the handle of a scope, which only recovers the checks of its scope, defer handle(&err) or check in the scope", name)
+	default:
+		c.pass.Reportf(call.Pos(), "check used in %s without defer handle(&err), the panic escapes to its callers", name)
+	}
+}
+
+// scope returns the scope of a method call on a scope, like s.Check0 or
+// s.Handle.
+func (c *checker) scope(call *ast.CallExpr) (ast.Expr, bool) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil, false
+	}
+	selection := c.pass.TypesInfo.Selections[sel]
+	if selection == nil || selection.Kind() != types.MethodVal || !c.isScope(selection.Recv()) {
+		return nil, false
+	}
+	return sel.X, true
+}
+
+// returnsScoped tells whether call returns a function taking the scope of
+// the check, like CheckIn.
+func (c *checker) returnsScoped(call *ast.CallExpr) bool {
+	sig, ok := c.pass.TypesInfo.TypeOf(call).(*types.Signature)
+	return ok && sig.Params().Len() == 1 && c.isScope(sig.Params().At(0).Type())
+}
+
+// object returns the variable holding a scope, nil if it is not held by one.
+func (c *checker) object(scope ast.Expr) types.Object {
+	ident, ok := ast.Unparen(scope).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	return c.pass.TypesInfo.ObjectOf(ident)
+}
+
+// isScope tells whether typ is a pointer to the scope type.
+func (c *checker) isScope(typ types.Type) bool {
+	ptr, ok := typ.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+	return named.Obj().Pkg().Path()+"."+named.Obj().Name() == scopeType
+}
+
+// scoped returns the *Scope parameters of a function type, the function
+// leaves the checks of these scopes to the handle of its caller.
+func (c *checker) scoped(typ *ast.FuncType) map[types.Object]bool {
+	scopes := make(map[types.Object]bool)
+	for _, field := range typ.Params.List {
+		if !c.isScope(c.pass.TypesInfo.TypeOf(field.Type)) {
+			continue
+		}
+		for _, name := range field.Names {
+			scopes[c.pass.TypesInfo.Defs[name]] = true
+		}
+	}
+	return scopes
+}
+
+// launched tells whether lit, with its ancestors in parents, is the function
+// of a go statement.
+func launched(parents []ast.Node, lit *ast.FuncLit) bool {
+	if len(parents) < 2 {
+		return false
+	}
+	call, ok := parents[len(parents)-1].(*ast.CallExpr)
+	if !ok || ast.Unparen(call.Fun) != ast.Expr(lit) {
+		return false
+	}
+	stmt, ok := parents[len(parents)-2].(*ast.GoStmt)
+	return ok && stmt.Call == call
+}
+
+// handleArgument reports handle calls not given a pointer to the named error
+// result of the function.
+func (c *checker) handleArgument(name string, typ *ast.FuncType, call *ast.CallExpr) {
+	results := typ.Results
+	var last *ast.Ident
+	if results != nil && len(results.List) > 0 {
+		field := results.List[len(results.List)-1]
+		if len(field.Names) > 0 && types.Identical(c.pass.TypesInfo.TypeOf(field.Type), errorType) {
+			last = field.Names[len(field.Names)-1]
+		}
+	}
+	if last == nil {
+		c.pass.Reportf(call.Pos(), "handle needs a pointer to the named error result, but the last result of %s is not a named error", name)
+		return
+	}
+
+	unary, ok := ast.Unparen(call.Args[0]).(*ast.UnaryExpr)
+	if ok {
+		if ident, ok := ast.Unparen(unary.X).(*ast.Ident); ok && c.pass.TypesInfo.Uses[ident] == c.pass.TypesInfo.Defs[last] {
+			return
+		}
+	}
+	c.pass.Reportf(call.Args[0].Pos(), "handle is given %s instead of &%s, the error result of %s", types.ExprString(call.Args[0]), last.Name, name)
+}
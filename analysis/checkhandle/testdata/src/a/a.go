@@ -0,0 +1,101 @@
+package a
+
+import (
+	"errors"
+	"os"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+	"other"
+)
+
+var errFailed = errors.New("failed")
+
+func handled() (err error) {
+	defer errorhandling.Handle(&err)
+
+	errorhandling.Check0(errFailed)
+	return nil
+}
+
+func unhandled() error {
+	errorhandling.Check0(errFailed) // want `check used in unhandled without defer handle\(&err\)`
+	return nil
+}
+
+func notDeferred() (err error) {
+	errorhandling.Handle(&err) // want `handle has to be deferred directly`
+	return nil
+}
+
+func wrongArgument() (err error) {
+	var other error
+	defer errorhandling.Handle(&other) // want `handle is given &other instead of &err`
+	return nil
+}
+
+func goroutine() (err error) {
+	defer errorhandling.Handle(&err)
+
+	go func() {
+		errorhandling.Check0(errFailed) // want `check in a goroutine is not recovered`
+	}()
+	return nil
+}
+
+func literal() (err error) {
+	defer errorhandling.Handle(&err)
+
+	func() {
+		errorhandling.Check0(errFailed)
+	}()
+	return nil
+}
+
+func scoped() (err error) {
+	s := errorhandling.NewScope()
+	defer s.Handle(&err)
+
+	s.Check0(errFailed)
+	f := errorhandling.CheckIn(os.Open("file"))(s)
+	defer f.Close()
+	return nil
+}
+
+func unscopedCheckInScope() (err error) {
+	s := errorhandling.NewScope()
+	defer s.Handle(&err)
+
+	errorhandling.Check0(errFailed) // want `check used in unscopedCheckInScope is not recovered by the handle of a scope`
+	return nil
+}
+
+func scopedCheckUnscoped() (err error) {
+	defer errorhandling.Handle(&err)
+
+	s := errorhandling.NewScope()
+	s.Check0(errFailed)                           // want `check of scope s used in scopedCheckUnscoped is not recovered by handle`
+	_ = errorhandling.CheckIn(os.Open("file"))(s) // want `check of scope s used in scopedCheckUnscoped is not recovered by handle`
+	return nil
+}
+
+func otherScope() (err error) {
+	s, t := errorhandling.NewScope(), errorhandling.NewScope()
+	defer s.Handle(&err)
+
+	t.Check0(errFailed) // want `check of scope t used in otherScope without defer t.Handle\(&err\)`
+	return nil
+}
+
+// readLine leaves the checks of scope to the handle of its caller.
+func readLine(scope *errorhandling.Scope) []byte {
+	return errorhandling.CheckIn(os.ReadFile("file"))(scope)
+}
+
+func scopeParameter(scope *errorhandling.Scope) {
+	errorhandling.Check0(errFailed) // want `check used in scopeParameter is not recovered by the handle of a scope`
+}
+
+// A Scope of another package does not leave the checks to the caller.
+func otherPackageScope(scope *other.Scope) {
+	errorhandling.Check0(errFailed) // want `check used in otherPackageScope without defer handle\(&err\)`
+}
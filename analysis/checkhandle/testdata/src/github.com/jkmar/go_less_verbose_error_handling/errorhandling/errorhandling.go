@@ -0,0 +1,20 @@
+// Package errorhandling stubs the check and handle functions.
+package errorhandling
+
+type Scope struct{}
+
+func NewScope() *Scope { return new(Scope) }
+
+func (s *Scope) Handle(err *error) {}
+
+func (s *Scope) Check0(err error) {}
+
+func Handle(err *error) {}
+
+func Check0(err error) {}
+
+func Check[T any](x T, err error) T { return x }
+
+func CheckIn[T any](x T, err error) func(s *Scope) T {
+	return func(s *Scope) T { return x }
+}
@@ -0,0 +1,6 @@
+// Package other declares a Scope which is not the one of errorhandling.
+package other
+
+type Scope struct{}
+
+func Check0(err error) {}
@@ -0,0 +1,546 @@
+// Package stickyerr defines an Analyzer reporting misuse of sticky error
+// types like ErrorReader, ErrorParser and ErrorChecker.
+//
+// A sticky error type is a named struct with an err field of type error and
+// an Err() error method with a pointer receiver. The analyzer reports
+//
+//   - local sticky values whose Err method is not called between their last
+//     use and a return, which silently drops the error, unless the return
+//     fails with an error of its own,
+//   - results of sticky methods used before Err is checked, as they may be
+//     zero values after a failure,
+//   - sticky values copied by value, as the copy does not share the error.
+//     Assigning one to the blank identifier is only a use.
+//
+// Branches are told apart, loops are not followed. A use on one branch of an
+// if or switch does not reach the returns of the others, and an Err call
+// only counts for the returns and uses in its own block, so checking it on
+// one branch does not cover the code after the branch.
+package stickyerr
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const Doc = `report misuse of sticky error types
+
+A sticky error type is a named struct with an err field of type error and an
+Err() error method with a pointer receiver, like ErrorReader or ErrorChecker.
+The analyzer reports sticky values whose Err is not checked before return,
+results of sticky methods used before Err is checked and sticky values copied
+by value.
+
+Err counts only for the returns and uses in the block of its call, a check on
+one branch does not cover the code after the branch. Loops are not followed.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "stickyerr",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodes := []ast.Node{
+		(*ast.FuncDecl)(nil),
+		(*ast.FuncLit)(nil),
+		(*ast.AssignStmt)(nil),
+		(*ast.ValueSpec)(nil),
+		(*ast.CallExpr)(nil),
+		(*ast.ReturnStmt)(nil),
+		(*ast.RangeStmt)(nil),
+		(*ast.CompositeLit)(nil),
+	}
+	inspect.Preorder(nodes, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			checkReceiver(pass, n)
+			if n.Body != nil {
+				checkFunction(pass, n.Body)
+			}
+		case *ast.FuncLit:
+			checkFunction(pass, n.Body)
+		default:
+			checkCopies(pass, n)
+		}
+	})
+	return nil, nil
+}
+
+// Sticky reports whether t is a sticky error type.
+func Sticky(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+
+	hasErr := false
+	for n := 0; n < st.NumFields(); n++ {
+		field := st.Field(n)
+		if field.Name() == "err" && types.Identical(field.Type(), errorType) {
+			hasErr = true
+		}
+	}
+	if !hasErr {
+		return false
+	}
+
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), false, named.Obj().Pkg(), "Err")
+	method, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	// Immutable types with value receivers, like Result, are not sticky.
+	sig := method.Type().(*types.Signature)
+	if _, ok := sig.Recv().Type().(*types.Pointer); !ok {
+		return false
+	}
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), errorType)
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+// stickyVar reports whether t is a sticky type or a pointer to one.
+func stickyVar(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	return Sticky(t)
+}
+
+type event struct {
+	pos  token.Pos
+	kind int
+	node ast.Node
+	// scope is the innermost block or case clause of an Err call.
+	scope ast.Node
+}
+
+const (
+	eventCall = iota
+	eventErr
+	eventReturn
+)
+
+// local is a sticky value declared in the analyzed function.
+type local struct {
+	obj     *types.Var
+	escaped bool
+	events  []event
+	calls   map[*ast.CallExpr]bool
+}
+
+func checkFunction(pass *analysis.Pass, body *ast.BlockStmt) {
+	locals := make(map[*types.Var]*local)
+	var returns []*ast.ReturnStmt
+
+	// Declarations and returns of this function, not of nested ones.
+	var parents []ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			parents = parents[:len(parents)-1]
+			return false
+		}
+		parents = append(parents, n)
+
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			parents = parents[:len(parents)-1]
+			return false
+		case *ast.ReturnStmt:
+			if !failing(pass, n, parents) {
+				returns = append(returns, n)
+			}
+		case *ast.Ident:
+			if v, ok := pass.TypesInfo.Defs[n].(*types.Var); ok && stickyVar(v.Type()) {
+				locals[v] = &local{obj: v, calls: make(map[*ast.CallExpr]bool)}
+			}
+		}
+		return true
+	})
+	if len(locals) == 0 {
+		return
+	}
+
+	collectUses(pass, body, locals, false)
+	parents = nil
+	parent := make(map[ast.Node]ast.Node)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			parents = parents[:len(parents)-1]
+			return false
+		}
+		if len(parents) > 0 {
+			parent[n] = parents[len(parents)-1]
+		}
+		parents = append(parents, n)
+		return true
+	})
+
+	for _, l := range locals {
+		if l.escaped || len(l.calls) == 0 {
+			continue
+		}
+		for _, ret := range returns {
+			l.events = append(l.events, event{pos: ret.End(), kind: eventReturn, node: ret})
+		}
+		l.events = append(l.events, event{pos: body.Rbrace, kind: eventReturn, node: body})
+		sort.Slice(l.events, func(i, j int) bool { return l.events[i].pos < l.events[j].pos })
+
+		checkErrChecked(pass, l, parent)
+		checkResults(pass, body, l)
+	}
+}
+
+// failing tells whether ret, with its ancestors in parents, returns an error
+// of its own: a new one, like fmt.Errorf(...), or one just checked not to be
+// nil. The function fails anyway, so the error of a sticky value is not
+// dropped silently.
+func failing(pass *analysis.Pass, ret *ast.ReturnStmt, parents []ast.Node) bool {
+	if len(ret.Results) == 0 {
+		return false
+	}
+	last := ast.Unparen(ret.Results[len(ret.Results)-1])
+	if t := pass.TypesInfo.TypeOf(last); t == nil || !types.Implements(t, errorType.Underlying().(*types.Interface)) {
+		return false
+	}
+
+	switch last := last.(type) {
+	case *ast.CallExpr, *ast.UnaryExpr, *ast.CompositeLit:
+		return true
+	case *ast.Ident:
+		// if err != nil { return ..., err }
+		if len(parents) < 3 {
+			return false
+		}
+		block, ok := parents[len(parents)-2].(*ast.BlockStmt)
+		if !ok {
+			return false
+		}
+		stmt, ok := parents[len(parents)-3].(*ast.IfStmt)
+		if !ok || stmt.Body != block {
+			return false
+		}
+		cond, ok := ast.Unparen(stmt.Cond).(*ast.BinaryExpr)
+		if !ok || cond.Op != token.NEQ {
+			return false
+		}
+		x, ok := ast.Unparen(cond.X).(*ast.Ident)
+		if !ok || pass.TypesInfo.Uses[x] == nil || pass.TypesInfo.Uses[x] != pass.TypesInfo.Uses[last] {
+			return false
+		}
+		nilIdent, ok := ast.Unparen(cond.Y).(*ast.Ident)
+		return ok && pass.TypesInfo.Uses[nilIdent] == types.Universe.Lookup("nil")
+	}
+	return false
+}
+
+// collectUses records the method calls of every local and marks the ones
+// used in any other way, or inside closures, as escaped.
+func collectUses(pass *analysis.Pass, root ast.Node, locals map[*types.Var]*local, nested bool) {
+	var parents []ast.Node
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			parents = parents[:len(parents)-1]
+			return false
+		}
+		if lit, ok := n.(*ast.FuncLit); ok && lit != root {
+			collectUses(pass, lit.Body, locals, true)
+			return false
+		}
+		parents = append(parents, n)
+
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		v, ok := pass.TypesInfo.Uses[ident].(*types.Var)
+		l := locals[v]
+		if !ok || l == nil {
+			return true
+		}
+		if nested {
+			l.escaped = true
+			return true
+		}
+
+		call := methodCall(pass, parents)
+		if call == nil {
+			l.escaped = true
+			return true
+		}
+		e := event{pos: call.Pos(), kind: eventCall, node: call}
+		if call.Fun.(*ast.SelectorExpr).Sel.Name == "Err" {
+			e.kind = eventErr
+			e.scope = block(parents)
+		} else {
+			l.calls[call] = true
+		}
+		l.events = append(l.events, e)
+		return true
+	})
+}
+
+// methodCall returns the call if the identifier on top of parents is the
+// receiver of a method call.
+func methodCall(pass *analysis.Pass, parents []ast.Node) *ast.CallExpr {
+	if len(parents) < 3 {
+		return nil
+	}
+	sel, ok := parents[len(parents)-2].(*ast.SelectorExpr)
+	if !ok || sel.X != parents[len(parents)-1] {
+		return nil
+	}
+	call, ok := parents[len(parents)-3].(*ast.CallExpr)
+	if !ok || call.Fun != sel {
+		return nil
+	}
+	if selection := pass.TypesInfo.Selections[sel]; selection == nil || selection.Kind() != types.MethodVal {
+		return nil
+	}
+	return call
+}
+
+// block returns the innermost block or case clause in parents.
+func block(parents []ast.Node) ast.Node {
+	for n := len(parents) - 1; n >= 0; n-- {
+		switch parents[n].(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+			return parents[n]
+		}
+	}
+	return nil
+}
+
+func checkErrChecked(pass *analysis.Pass, l *local, parent map[ast.Node]ast.Node) {
+	reported := make(map[ast.Node]bool)
+	for n, e := range l.events {
+		if e.kind != eventReturn {
+			continue
+		}
+		// The last call which may run before the return.
+		var call *ast.CallExpr
+		for m := n - 1; m >= 0 && call == nil; m-- {
+			if l.events[m].kind == eventCall && !exclusive(parent, l.events[m].node, e.node) {
+				call = l.events[m].node.(*ast.CallExpr)
+			}
+		}
+		// One report per unchecked call is enough.
+		if call == nil || reported[call] || errCheckedBetween(l, call.Pos(), e.pos) {
+			continue
+		}
+		reported[call] = true
+		if _, ok := e.node.(*ast.BlockStmt); ok {
+			pass.Reportf(call.Pos(), "%s.Err() is never checked after this call, its error is dropped", l.obj.Name())
+		} else {
+			pass.Reportf(e.node.Pos(), "%s.Err() is not checked before return, the error of %s.%s is dropped",
+				l.obj.Name(), l.obj.Name(), call.Fun.(*ast.SelectorExpr).Sel.Name)
+		}
+	}
+}
+
+// exclusive reports whether a and b are on different branches of an if or
+// in different clauses of a switch or select, so only one of them runs.
+func exclusive(parent map[ast.Node]ast.Node, a, b ast.Node) bool {
+	// The child of every ancestor of a on the way to a.
+	children := make(map[ast.Node]ast.Node)
+	for child, n := a, parent[a]; n != nil; child, n = n, parent[n] {
+		children[n] = child
+	}
+	for child, n := b, parent[b]; n != nil; child, n = n, parent[n] {
+		other, ok := children[n]
+		if !ok {
+			continue
+		}
+		if other == child {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			return (other == n.Body || other == n.Else) && (child == n.Body || child == n.Else)
+		case *ast.BlockStmt:
+			return clause(other) && clause(child)
+		}
+		return false
+	}
+	return false
+}
+
+func clause(n ast.Node) bool {
+	switch n.(type) {
+	case *ast.CaseClause, *ast.CommClause:
+		return true
+	}
+	return false
+}
+
+// checkResults reports results of sticky calls used before Err is checked.
+func checkResults(pass *analysis.Pass, body *ast.BlockStmt, l *local) {
+	results := make(map[types.Object]*ast.CallExpr)
+	ast.Inspect(body, func(n ast.Node) bool {
+		var (
+			lhs []ast.Expr
+			rhs []ast.Expr
+		)
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			lhs, rhs = n.Lhs, n.Rhs
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				lhs = append(lhs, name)
+			}
+			rhs = n.Values
+		default:
+			return true
+		}
+		if len(rhs) != 1 {
+			return true
+		}
+		call, ok := ast.Unparen(rhs[0]).(*ast.CallExpr)
+		if !ok || !l.calls[call] {
+			return true
+		}
+		for _, expr := range lhs {
+			ident, ok := expr.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if obj := pass.TypesInfo.ObjectOf(ident); obj != nil {
+				results[obj] = call
+			}
+		}
+		return true
+	})
+	if len(results) == 0 {
+		return
+	}
+
+	reported := make(map[types.Object]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok && l.calls[call] {
+			// Arguments of further sticky calls are fine, the calls are
+			// skipped after a failure.
+			return false
+		}
+		if ret, ok := n.(*ast.ReturnStmt); ok && errCheckedBetween(l, ret.Pos(), ret.End()) {
+			// return result, sticky.Err()
+			return false
+		}
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := pass.TypesInfo.Uses[ident]
+		call, ok := results[obj]
+		if !ok || reported[obj] || ident.Pos() < call.End() {
+			return true
+		}
+		if !errCheckedBetween(l, call.End(), ident.Pos()) {
+			reported[obj] = true
+			pass.Reportf(ident.Pos(), "%s is used before %s.Err() is checked, it may be a zero value", ident.Name, l.obj.Name())
+		}
+		return true
+	})
+}
+
+// errCheckedBetween reports whether Err is called between from and to, in a
+// block which also holds to.
+func errCheckedBetween(l *local, from, to token.Pos) bool {
+	for _, e := range l.events {
+		if e.kind == eventErr && from <= e.pos && e.pos < to && e.scope.Pos() <= to && to <= e.scope.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCopies reports sticky values copied by value.
+func checkCopies(pass *analysis.Pass, n ast.Node) {
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		for i, expr := range n.Rhs {
+			// _ = c only uses c.
+			if len(n.Lhs) == len(n.Rhs) && blank(n.Lhs[i]) {
+				continue
+			}
+			reportCopy(pass, expr, "assignment copies")
+		}
+	case *ast.ValueSpec:
+		for i, expr := range n.Values {
+			if len(n.Names) == len(n.Values) && blank(n.Names[i]) {
+				continue
+			}
+			reportCopy(pass, expr, "variable declaration copies")
+		}
+	case *ast.CallExpr:
+		if tv, ok := pass.TypesInfo.Types[n.Fun]; ok && tv.IsType() {
+			return
+		}
+		for _, expr := range n.Args {
+			reportCopy(pass, expr, "call passes a copy of")
+		}
+	case *ast.ReturnStmt:
+		for _, expr := range n.Results {
+			reportCopy(pass, expr, "return copies")
+		}
+	case *ast.CompositeLit:
+		for _, expr := range n.Elts {
+			if kv, ok := expr.(*ast.KeyValueExpr); ok {
+				expr = kv.Value
+			}
+			reportCopy(pass, expr, "composite literal copies")
+		}
+	case *ast.RangeStmt:
+		if n.Value == nil {
+			return
+		}
+		if t := pass.TypesInfo.TypeOf(n.Value); t != nil && Sticky(t) {
+			pass.Reportf(n.Value.Pos(), "range variable %s copies a sticky error value of type %s", types.ExprString(n.Value), t)
+		}
+	}
+}
+
+func blank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
+
+func reportCopy(pass *analysis.Pass, expr ast.Expr, what string) {
+	t := pass.TypesInfo.TypeOf(expr)
+	if t == nil || !Sticky(t) {
+		return
+	}
+	switch ast.Unparen(expr).(type) {
+	case *ast.CompositeLit, *ast.CallExpr:
+		// A new value, nothing is shared.
+		return
+	}
+	pass.Reportf(expr.Pos(), "%s sticky error value %s of type %s, use a pointer", what, types.ExprString(expr), t)
+}
+
+// checkReceiver reports methods of sticky types with value receivers, which
+// operate on a copy.
+func checkReceiver(pass *analysis.Pass, decl *ast.FuncDecl) {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 || decl.Name.Name == "Err" {
+		return
+	}
+	t := pass.TypesInfo.TypeOf(decl.Recv.List[0].Type)
+	if t != nil && Sticky(t) {
+		pass.Reportf(decl.Recv.List[0].Type.Pos(), "method %s has a value receiver of sticky error type %s, errors it records are lost", decl.Name.Name, t)
+	}
+}
@@ -0,0 +1,13 @@
+package stickyerr_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/jkmar/go_less_verbose_error_handling/analysis/stickyerr"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), stickyerr.Analyzer, "a")
+}
@@ -0,0 +1,146 @@
+package a
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type Parser struct {
+	err error
+}
+
+func (p *Parser) Err() error {
+	return p.err
+}
+
+func (p *Parser) Atoi(s string) int {
+	if p.err != nil {
+		return 0
+	}
+	var result int
+	result, p.err = strconv.Atoi(s)
+	return result
+}
+
+func (p Parser) Reset() { // want `method Reset has a value receiver of sticky error type a.Parser`
+}
+
+func checked(s string) (int, error) {
+	p := &Parser{}
+	n := p.Atoi(s)
+	if err := p.Err(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func returnedWithErr(s string) (int, error) {
+	p := &Parser{}
+	n := p.Atoi(s)
+	return n, p.Err()
+}
+
+func unchecked(s string) int {
+	p := &Parser{}
+	n := p.Atoi(s)
+	return n // want `n is used before p.Err\(\) is checked` `p.Err\(\) is not checked before return, the error of p.Atoi is dropped`
+}
+
+func neverChecked(s string) {
+	p := &Parser{}
+	p.Atoi(s) // want `p.Err\(\) is never checked after this call, its error is dropped`
+}
+
+func usedBeforeErr(s string) error {
+	p := &Parser{}
+	n := p.Atoi(s)
+	if n > 10 { // want `n is used before p.Err\(\) is checked, it may be a zero value`
+		return errors.New("too large")
+	}
+	return p.Err()
+}
+
+func argumentOfStickyCall(s string) (int, error) {
+	p := &Parser{}
+	n := p.Atoi(s)
+	m := p.Atoi(strconv.Itoa(n))
+	return m, p.Err()
+}
+
+// ownError returns errors of its own from inside the loop of a sticky
+// bufio.Scanner, only the end of the loop needs its Err.
+func ownError(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			return fmt.Errorf("empty line")
+		}
+		if _, err := strconv.Atoi(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func droppedInLoop(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			return nil // want `scanner.Err\(\) is not checked before return, the error of scanner.Text is dropped`
+		}
+	}
+	return scanner.Err()
+}
+
+func uncheckedErrorVariable(r io.Reader, err error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Scan()
+	return err // want `scanner.Err\(\) is not checked before return, the error of scanner.Scan is dropped`
+}
+
+func checkedOnOneBranch(s string, strict bool) (int, error) {
+	p := &Parser{}
+	n := p.Atoi(s)
+	if strict {
+		if err := p.Err(); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil // want `n is used before p.Err\(\) is checked` `p.Err\(\) is not checked before return, the error of p.Atoi is dropped`
+}
+
+func otherClause(s string, parse bool) (int, error) {
+	p := &Parser{}
+	var n int
+	switch {
+	case parse:
+		n = p.Atoi(s)
+	default:
+		return 0, nil
+	}
+	return n, p.Err()
+}
+
+func otherBranch(s string, parse bool) (int, error) {
+	p := &Parser{}
+	var n int
+	if parse {
+		n = p.Atoi(s)
+	} else {
+		return 0, nil
+	}
+	return n, p.Err()
+}
+
+func copies(p *Parser) {
+	q := *p // want `assignment copies sticky error value \*p of type a.Parser, use a pointer`
+	_ = q.Err()
+}
+
+func blankCopies(p *Parser) {
+	_ = *p
+	var _ = *p
+}
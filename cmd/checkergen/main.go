@@ -0,0 +1,301 @@
+// Command checkergen generates ErrorChecker-style sticky error wrappers for
+// the functions of the given packages.
+//
+// For every exported function whose last result is error it emits a method
+// on the receiver type that does nothing and returns zero values once the
+// receiver holds an error, and otherwise calls the function and stores its
+// error:
+//
+//	//go:generate go run github.com/jkmar/go_less_verbose_error_handling/cmd/checkergen -type ErrorChecker strconv encoding/json
+//
+// generates ErrorChecker.StrconvAtoi, ErrorChecker.JsonUnmarshal and so on,
+// -funcs strconv.Atoi,json.Unmarshal only those two.
+// Step and Wrap of the declared type apply to the error of the next call
+// only.
+//
+// With -accumulate the methods always call the function and the type, like
+// ValidationChecker, records every error with its call site and returns
+// them joined by errors.Join.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jkmar/go_less_verbose_error_handling/internal/gen"
+)
+
+const generator = "checkergen"
+
+type config struct {
+	typeName string
+	receiver string
+	naming   *template.Template
+	pkg      string
+	declare  bool
+	// funcs restricts the wrapped functions to the listed package.Func
+	// names, all are wrapped when it is empty.
+	funcs map[string]bool
+	// accumulate generates a ValidationChecker-style type which calls every
+	// function and records all errors instead of stopping at the first one.
+	accumulate bool
+}
+
+type name struct {
+	Package     string
+	PackageName string
+	Func        string
+}
+
+func main() {
+	var (
+		typeName   = flag.String("type", "ErrorChecker", "name of the receiver type")
+		receiver   = flag.String("receiver", "c", "name of the receiver variable")
+		naming     = flag.String("name", "{{.Package}}{{.Func}}", "template of the method names, with fields .Package (capitalized package name), .PackageName and .Func")
+		output     = flag.String("output", "", "output file; defaults to <type>_gen.go")
+		funcs      = flag.String("funcs", "", "comma separated functions to wrap, like strconv.Atoi; defaults to all exported functions of the packages")
+		pkg        = flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file; defaults to $GOPACKAGE")
+		declare    = flag.Bool("declare", true, "also declare the receiver type, its constructor and the Err, Step and Wrap methods")
+		accumulate = flag.Bool("accumulate", false, "call every function and record all errors with their call sites, like ValidationChecker, instead of stopping at the first one")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] package...\n", generator)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	tmpl, err := template.New("name").Parse(*naming)
+	if err != nil {
+		fatal(err)
+	}
+	if *pkg == "" {
+		*pkg = "main"
+	}
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_gen.go"
+	}
+
+	src, err := generate(&config{
+		typeName:   *typeName,
+		receiver:   *receiver,
+		naming:     tmpl,
+		pkg:        *pkg,
+		declare:    *declare,
+		accumulate: *accumulate,
+		funcs:      set(*funcs),
+	}, flag.Args())
+	if err != nil {
+		fatal(err)
+	}
+
+	if err = os.WriteFile(*output, src, 0o644); err != nil {
+		fatal(err)
+	}
+}
+
+func set(list string) map[string]bool {
+	result := make(map[string]bool)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result[item] = true
+		}
+	}
+	return result
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", generator, err)
+	os.Exit(1)
+}
+
+func generate(cfg *config, paths []string) ([]byte, error) {
+	imports := gen.NewImports("")
+
+	var funcs []*types.Func
+	for _, path := range paths {
+		pkg, err := gen.LoadPackage(path)
+		if err != nil {
+			return nil, err
+		}
+		funcs = append(funcs, wrappable(pkg, cfg.funcs)...)
+	}
+	if len(funcs) == 0 {
+		return nil, fmt.Errorf("no functions to wrap in %s", strings.Join(paths, ", "))
+	}
+
+	// Register every import before choosing parameter names so that no
+	// parameter shadows a package used by the generated code.
+	for _, f := range funcs {
+		imports.Name(f.Pkg())
+		sig := f.Type().(*types.Signature)
+		imports.Params(sig, make([]string, sig.Params().Len()))
+		imports.ResultList(imports.Results(sig))
+	}
+
+	var body bytes.Buffer
+	switch {
+	case cfg.declare && cfg.accumulate:
+		writeAccumulatingDeclaration(&body, cfg, imports)
+	case cfg.declare:
+		writeDeclaration(&body, cfg, imports)
+	}
+
+	methods := make(map[string]string)
+	for _, f := range funcs {
+		method, err := methodName(cfg, f)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.declare && (method == "Err" || method == "Errs" || method == "Step" || method == "Wrap" || method == "set" || method == "record") {
+			return nil, fmt.Errorf("%s.%s would be named %s, which is declared by the generated type", f.Pkg().Path(), f.Name(), method)
+		}
+		if previous, ok := methods[method]; ok {
+			return nil, fmt.Errorf("%s and %s.%s are both named %s", previous, f.Pkg().Path(), f.Name(), method)
+		}
+		methods[method] = f.Pkg().Path() + "." + f.Name()
+
+		writeMethod(&body, cfg, imports, method, f)
+	}
+
+	return gen.Source(generator, cfg.pkg, imports, body.Bytes())
+}
+
+func wrappable(pkg *types.Package, include map[string]bool) []*types.Func {
+	var funcs []*types.Func
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		f, ok := scope.Lookup(name).(*types.Func)
+		if !ok || !gen.Exported(f) || len(include) > 0 && !include[pkg.Name()+"."+f.Name()] {
+			continue
+		}
+		sig := f.Type().(*types.Signature)
+		if sig.TypeParams().Len() > 0 || !gen.ReturnsError(sig) {
+			continue
+		}
+		funcs = append(funcs, f)
+	}
+	return funcs
+}
+
+func methodName(cfg *config, f *types.Func) (string, error) {
+	var buf bytes.Buffer
+	err := cfg.naming.Execute(&buf, name{
+		Package:     capitalize(f.Pkg().Name()),
+		PackageName: f.Pkg().Name(),
+		Func:        f.Name(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("naming %s.%s: %w", f.Pkg().Path(), f.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func writeDeclaration(buf *bytes.Buffer, cfg *config, imports *gen.Imports) {
+	fmtName := imports.Name(types.NewPackage("fmt", "fmt"))
+	fmt.Fprintf(buf, "type %s struct {\n\terr  error\n\twrap func(error) error\n}\n\n", cfg.typeName)
+	fmt.Fprintf(buf, "func New%s() *%[1]s {\n\treturn &%[1]s{}\n}\n\n", cfg.typeName)
+	fmt.Fprintf(buf, "func (%s *%s) Err() error {\n\treturn %[1]s.err\n}\n\n", cfg.receiver, cfg.typeName)
+	fmt.Fprintf(buf, "func (%s *%s) Step(name string) *%[2]s {\n\treturn %[1]s.Wrap(func(err error) error {\n\t\treturn %[3]s.Errorf(\"%%s: %%w\", name, err)\n\t})\n}\n\n", cfg.receiver, cfg.typeName, fmtName)
+	fmt.Fprintf(buf, "func (%s *%s) Wrap(wrap func(error) error) *%[2]s {\n\tif %[1]s.err == nil {\n\t\t%[1]s.wrap = wrap\n\t}\n\treturn %[1]s\n}\n\n", cfg.receiver, cfg.typeName)
+	fmt.Fprintf(buf, "func (%s *%s) set(err error) {\n\tif err != nil && %[1]s.wrap != nil {\n\t\terr = %[1]s.wrap(err)\n\t}\n\t%[1]s.err, %[1]s.wrap = err, nil\n}\n\n", cfg.receiver, cfg.typeName)
+}
+
+func writeAccumulatingDeclaration(buf *bytes.Buffer, cfg *config, imports *gen.Imports) {
+	errorsName := imports.Name(types.NewPackage("errors", "errors"))
+	fmtName := imports.Name(types.NewPackage("fmt", "fmt"))
+	filepathName := imports.Name(types.NewPackage("path/filepath", "filepath"))
+	runtimeName := imports.Name(types.NewPackage("runtime", "runtime"))
+	fmt.Fprintf(buf, "type %s struct {\n\terrs []error\n\twrap func(error) error\n}\n\n", cfg.typeName)
+	fmt.Fprintf(buf, "func New%s() *%[1]s {\n\treturn &%[1]s{}\n}\n\n", cfg.typeName)
+	fmt.Fprintf(buf, "func (%s *%s) Err() error {\n\treturn %s.Join(%[1]s.errs...)\n}\n\n", cfg.receiver, cfg.typeName, errorsName)
+	fmt.Fprintf(buf, "func (%s *%s) Errs() []error {\n\treturn %[1]s.errs\n}\n\n", cfg.receiver, cfg.typeName)
+	fmt.Fprintf(buf, "func (%s *%s) Step(name string) *%[2]s {\n\treturn %[1]s.Wrap(func(err error) error {\n\t\treturn %[3]s.Errorf(\"%%s: %%w\", name, err)\n\t})\n}\n\n", cfg.receiver, cfg.typeName, fmtName)
+	fmt.Fprintf(buf, "func (%s *%s) Wrap(wrap func(error) error) *%[2]s {\n\t%[1]s.wrap = wrap\n\treturn %[1]s\n}\n\n", cfg.receiver, cfg.typeName)
+	fmt.Fprintf(buf, `func (%[1]s *%[2]s) record(err error) {
+	wrap := %[1]s.wrap
+	%[1]s.wrap = nil
+	if err == nil {
+		return
+	}
+	if wrap != nil {
+		err = wrap(err)
+	}
+	if _, file, line, ok := %[5]s.Caller(2); ok {
+		err = %[3]s.Errorf("%%s:%%d: %%w", %[4]s.Base(file), line, err)
+	}
+	%[1]s.errs = append(%[1]s.errs, err)
+}
+
+`, cfg.receiver, cfg.typeName, fmtName, filepathName, runtimeName)
+}
+
+func writeMethod(buf *bytes.Buffer, cfg *config, imports *gen.Imports, method string, f *types.Func) {
+	sig := f.Type().(*types.Signature)
+	results := imports.Results(sig)
+	names := gen.ParamNames(sig, func(name string) bool {
+		return name == cfg.receiver || name == "err" || strings.HasPrefix(name, "result") || imports.Used(name)
+	})
+	resultNames := make([]string, len(results))
+	for n := range results {
+		resultNames[n] = "result"
+		if len(results) > 1 {
+			resultNames[n] += fmt.Sprint(n)
+		}
+	}
+
+	fmt.Fprintf(buf, "func (%s *%s) %s(%s) %s {\n", cfg.receiver, cfg.typeName, method, imports.Params(sig, names), imports.ResultList(results))
+	call := fmt.Sprintf("%s.%s(%s)", imports.Name(f.Pkg()), f.Name(), gen.Args(sig, names))
+	if cfg.accumulate {
+		if len(results) == 0 {
+			fmt.Fprintf(buf, "\t%s.record(%s)\n}\n\n", cfg.receiver, call)
+			return
+		}
+		fmt.Fprintf(buf, "\t%s, err := %s\n", strings.Join(resultNames, ", "), call)
+		fmt.Fprintf(buf, "\t%s.record(err)\n", cfg.receiver)
+		fmt.Fprintf(buf, "\treturn %s\n}\n\n", strings.Join(resultNames, ", "))
+		return
+	}
+
+	fmt.Fprintf(buf, "\tif %s.err != nil {\n\t\treturn %s\n\t}\n\n", cfg.receiver, imports.Zeros(results))
+
+	// The declared type wraps the error of the call following Step or Wrap,
+	// a type declared elsewhere only needs the err field.
+	if cfg.declare {
+		if len(results) == 0 {
+			fmt.Fprintf(buf, "\t%s.set(%s)\n}\n\n", cfg.receiver, call)
+			return
+		}
+		fmt.Fprintf(buf, "\t%s, err := %s\n", strings.Join(resultNames, ", "), call)
+		fmt.Fprintf(buf, "\t%s.set(err)\n", cfg.receiver)
+		fmt.Fprintf(buf, "\treturn %s\n}\n\n", strings.Join(resultNames, ", "))
+		return
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintf(buf, "\t%s.err = %s\n}\n\n", cfg.receiver, call)
+		return
+	}
+
+	for n, t := range results {
+		fmt.Fprintf(buf, "\tvar %s %s\n", resultNames[n], imports.TypeString(t))
+	}
+	fmt.Fprintf(buf, "\t%s, %s.err = %s\n", strings.Join(resultNames, ", "), cfg.receiver, call)
+	fmt.Fprintf(buf, "\treturn %s\n}\n\n", strings.Join(resultNames, ", "))
+}
@@ -0,0 +1,14 @@
+// Command checkhandle runs the checkhandle analyzer, standalone or with
+//
+//	go vet -vettool=$(which checkhandle) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/jkmar/go_less_verbose_error_handling/analysis/checkhandle"
+)
+
+func main() {
+	singlechecker.Main(checkhandle.Analyzer)
+}
@@ -0,0 +1,86 @@
+// Command errrefactor finds repetitive error checks in Go packages and
+// suggests rewriting them with the patterns presented in the slides:
+//
+//   - the same method called repeatedly on one value becomes a sticky error
+//     wrapper like ErrorReader (see wrappergen),
+//   - calls of different library functions share an ErrorChecker
+//     (see checkergen),
+//   - calls of custom functions are passed as steps to Do,
+//   - a chain of calls, each consuming the single-use result of the previous
+//     one, becomes a typed Pipe2/Pipe3 pipeline, the type-safe DoEither.
+//
+// Errors returned annotated keep their annotation: the name of
+// fmt.Errorf("<name>: %w", err) goes to Step, StepFunc or the Step of the
+// sticky value, and the rest of the annotation, if all the calls share it,
+// to the returned error. Other annotations go to Wrap or WrapFunc, or stay in
+// the step given to Do.
+//
+// Every suggestion is reported on standard error and the rewritten files are
+// printed as a unified diff, or written in place with -w.
+//
+// Usage:
+//
+//	errrefactor [-w] [-lib importpath] [packages]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	defaultLib = "github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+
+	loadMode = packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps
+)
+
+func main() {
+	var (
+		write = flag.Bool("w", false, "write the rewritten files instead of printing a diff")
+		lib   = flag.String("lib", defaultLib, "import path of the package providing Do, Pipe2 and Pipe3")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: errrefactor [-w] [-lib importpath] [packages]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	pkgs, err := packages.Load(&packages.Config{Mode: loadMode}, patterns...)
+	if err != nil {
+		fatal(err)
+	}
+
+	failed := false
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			for _, err := range pkg.Errors {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			failed = true
+			continue
+		}
+
+		for _, file := range pkg.Syntax {
+			if err := refactorFile(pkg, file, *lib, *write); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "errrefactor: %v\n", err)
+	os.Exit(1)
+}
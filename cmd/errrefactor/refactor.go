@@ -0,0 +1,1057 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+type kind int
+
+const (
+	kindChain kind = iota
+	kindReader
+	kindChecker
+	kindDo
+)
+
+// block is a call whose error is checked right away:
+//
+//	x, err := f()
+//	if err != nil {
+//		return ..., err
+//	}
+//
+// or
+//
+//	if err := f(); err != nil {
+//		return ..., fmt.Errorf("f: %w", err)
+//	}
+type block struct {
+	stmts   []ast.Stmt
+	assign  *ast.AssignStmt
+	call    *ast.CallExpr
+	ret     *ast.ReturnStmt
+	errName string
+	errObj  types.Object
+	// wrap is the last result of ret when it annotates the error instead
+	// of returning it as is.
+	wrap ast.Expr
+	kind kind
+	key  string
+}
+
+// item is either a block or a statement between blocks.
+type item struct {
+	block *block
+	stmt  ast.Stmt
+}
+
+func (it item) span() (token.Pos, token.Pos) {
+	if it.block == nil {
+		return it.stmt.Pos(), it.stmt.End()
+	}
+	return it.block.stmts[0].Pos(), it.block.stmts[len(it.block.stmts)-1].End()
+}
+
+type edit struct {
+	pos, end token.Pos
+	text     string
+}
+
+type refactorer struct {
+	pkg       *packages.Package
+	fset      *token.FileSet
+	file      *ast.File
+	src       []byte
+	tokenFile *token.File
+	lib       string
+	libName   string
+	useLib    bool
+	edits     []edit
+	messages  []string
+}
+
+func refactorFile(pkg *packages.Package, file *ast.File, lib string, write bool) error {
+	filename := pkg.Fset.File(file.Pos()).Name()
+	src, out, messages, err := rewrite(pkg, file, lib)
+	if err != nil || out == nil {
+		return err
+	}
+
+	for _, message := range messages {
+		fmt.Fprintln(os.Stderr, message)
+	}
+	if write {
+		return os.WriteFile(filename, out, 0o644)
+	}
+
+	name := filename
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, filename); err == nil {
+			name = rel
+		}
+	}
+	_, err = os.Stdout.Write(unifiedDiff(filepath.ToSlash(name), src, out))
+	return err
+}
+
+// rewrite returns the source of file and the source with every suggestion
+// applied, nil if there is none, with the messages describing them.
+func rewrite(pkg *packages.Package, file *ast.File, lib string) (src, out []byte, messages []string, err error) {
+	filename := pkg.Fset.File(file.Pos()).Name()
+	src, err = os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	r := &refactorer{
+		pkg:       pkg,
+		fset:      pkg.Fset,
+		file:      file,
+		src:       src,
+		tokenFile: pkg.Fset.File(file.Pos()),
+		lib:       lib,
+		libName:   filepath.Base(lib),
+	}
+	if pkg.PkgPath == lib {
+		r.libName = ""
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			r.statements(n.List)
+		case *ast.CaseClause:
+			r.statements(n.Body)
+		case *ast.CommClause:
+			r.statements(n.Body)
+		}
+		return true
+	})
+	if len(r.edits) == 0 {
+		return src, nil, nil, nil
+	}
+
+	out, err = r.apply(filename)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return src, out, r.messages, nil
+}
+
+func (r *refactorer) text(node ast.Node) string {
+	return string(r.src[r.tokenFile.Offset(node.Pos()):r.tokenFile.Offset(node.End())])
+}
+
+func (r *refactorer) report(pos token.Pos, format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf("%s: %s", r.fset.Position(pos), fmt.Sprintf(format, args...)))
+}
+
+func (r *refactorer) qualified(name string) string {
+	r.useLib = r.libName != ""
+	if r.libName == "" {
+		return name
+	}
+	return r.libName + "." + name
+}
+
+func (r *refactorer) apply(filename string) ([]byte, error) {
+	sort.Slice(r.edits, func(i, j int) bool {
+		return r.edits[i].pos < r.edits[j].pos
+	})
+
+	var buf bytes.Buffer
+	cur := 0
+	for _, e := range r.edits {
+		buf.Write(r.src[cur:r.tokenFile.Offset(e.pos)])
+		buf.WriteString(e.text)
+		cur = r.tokenFile.Offset(e.end)
+	}
+	buf.Write(r.src[cur:])
+
+	if !r.useLib {
+		return format.Source(buf.Bytes())
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", buf.Bytes(), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	astutil.AddImport(fset, f, r.lib)
+
+	var out bytes.Buffer
+	if err := format.Node(&out, fset, f); err != nil {
+		return nil, err
+	}
+	// AddImport puts the library next to the imports sharing the most of
+	// its path, often the standard library, Process separates the groups
+	// again.
+	return imports.Process(filename, out.Bytes(), &imports.Options{
+		Comments:   true,
+		TabIndent:  true,
+		TabWidth:   8,
+		FormatOnly: true,
+	})
+}
+
+// indent returns the indentation of the line containing pos.
+func (r *refactorer) indent(pos token.Pos) string {
+	offset := r.tokenFile.Offset(pos)
+	start := bytes.LastIndexByte(r.src[:offset], '\n') + 1
+	line := r.src[start:offset]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+func (r *refactorer) replace(items []item, lines []string) {
+	pos, _ := items[0].span()
+	_, end := items[len(items)-1].span()
+
+	indent := r.indent(pos)
+	text := strings.Join(lines, "\n")
+	text = strings.ReplaceAll(text, "\n", "\n"+indent)
+	r.edits = append(r.edits, edit{pos: pos, end: end, text: text})
+}
+
+func (r *refactorer) statements(list []ast.Stmt) {
+	var items []item
+	for n := 0; n < len(list); {
+		if b, size := r.blockAt(list, n); b != nil {
+			items = append(items, item{block: b})
+			n += size
+			continue
+		}
+		items = append(items, item{stmt: list[n]})
+		n++
+	}
+
+	items = r.chains(items)
+	r.groups(items)
+}
+
+func (r *refactorer) blockAt(list []ast.Stmt, n int) (*block, int) {
+	if check, ok := list[n].(*ast.IfStmt); ok && check.Init != nil {
+		assign, ok := check.Init.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 1 {
+			return nil, 0
+		}
+		b := r.newBlock(assign, check)
+		if b == nil {
+			return nil, 0
+		}
+		b.stmts = []ast.Stmt{check}
+		return b, 1
+	}
+
+	assign, ok := list[n].(*ast.AssignStmt)
+	if !ok || n+1 == len(list) {
+		return nil, 0
+	}
+	check, ok := list[n+1].(*ast.IfStmt)
+	if !ok || check.Init != nil {
+		return nil, 0
+	}
+	b := r.newBlock(assign, check)
+	if b == nil {
+		return nil, 0
+	}
+	b.stmts = []ast.Stmt{assign, check}
+	return b, 2
+}
+
+func (r *refactorer) newBlock(assign *ast.AssignStmt, check *ast.IfStmt) *block {
+	if assign.Tok != token.DEFINE && assign.Tok != token.ASSIGN || len(assign.Rhs) != 1 {
+		return nil
+	}
+	call, ok := ast.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	errIdent, ok := assign.Lhs[len(assign.Lhs)-1].(*ast.Ident)
+	if !ok || errIdent.Name == "_" {
+		return nil
+	}
+
+	cond, ok := check.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.NEQ || !isIdent(cond.X, errIdent.Name) || !isIdent(cond.Y, "nil") {
+		return nil
+	}
+	if check.Else != nil || len(check.Body.List) != 1 {
+		return nil
+	}
+	ret, ok := check.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) == 0 {
+		return nil
+	}
+
+	b := &block{
+		assign:  assign,
+		call:    call,
+		ret:     ret,
+		errName: errIdent.Name,
+		errObj:  r.pkg.TypesInfo.ObjectOf(errIdent),
+	}
+	if b.errObj == nil {
+		return nil
+	}
+	for _, result := range ret.Results[:len(ret.Results)-1] {
+		if r.refersTo(result, b.errObj) {
+			return nil
+		}
+	}
+	// The error is either returned as is or annotated, like
+	// fmt.Errorf("f: %w", err) or NewFError(err).
+	if last := ret.Results[len(ret.Results)-1]; !r.isErr(last, b) {
+		if !r.refersTo(last, b.errObj) {
+			return nil
+		}
+		b.wrap = last
+	}
+
+	b.kind, b.key = r.classify(call)
+	b.key += "|" + r.ret(b, "err")
+	return b
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+// isErr reports whether expr is the error variable of b.
+func (r *refactorer) isErr(expr ast.Expr, b *block) bool {
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	return ok && r.pkg.TypesInfo.Uses[ident] == b.errObj
+}
+
+// refersTo reports whether node uses obj.
+func (r *refactorer) refersTo(node ast.Node, obj types.Object) bool {
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && r.pkg.TypesInfo.Uses[ident] == obj {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// renamed returns the source of node with the error variable of b renamed
+// to err.
+func (r *refactorer) renamed(node ast.Node, b *block) string {
+	var buf strings.Builder
+	cur := node.Pos()
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && r.pkg.TypesInfo.Uses[ident] == b.errObj {
+			buf.Write(r.src[r.tokenFile.Offset(cur):r.tokenFile.Offset(ident.Pos())])
+			buf.WriteString("err")
+			cur = ident.End()
+		}
+		return true
+	})
+	buf.Write(r.src[r.tokenFile.Offset(cur):r.tokenFile.Offset(node.End())])
+	return buf.String()
+}
+
+// ret returns the return statement of b with last as its error result.
+func (r *refactorer) ret(b *block, last string) string {
+	results := make([]string, len(b.ret.Results))
+	for n, result := range b.ret.Results[:len(results)-1] {
+		results[n] = r.text(result)
+	}
+	results[len(results)-1] = last
+	return "return " + strings.Join(results, ", ")
+}
+
+// annotation is the annotation of a block split into the name of its step
+// and the outer annotation, which the blocks of a rewrite have to share:
+// fmt.Errorf("%s: read: %w", filename, err) is the step read annotated by
+// fmt.Errorf("%s: %w", filename, err).
+type annotation struct {
+	name   string
+	errorf string
+	format string
+	args   []ast.Expr
+}
+
+// annotation splits the annotation of b, an error returned as is has
+// neither a name nor an outer annotation.
+func (r *refactorer) annotation(b *block) (annotation, bool) {
+	if b.wrap == nil {
+		return annotation{}, true
+	}
+	call, ok := b.wrap.(*ast.CallExpr)
+	if !ok || len(call.Args) < 2 || call.Ellipsis.IsValid() || !r.isErr(call.Args[len(call.Args)-1], b) {
+		return annotation{}, false
+	}
+	if f, ok := r.pkg.TypesInfo.Uses[calleeIdent(call)].(*types.Func); !ok || f.Pkg() == nil || f.Pkg().Path() != "fmt" || f.Name() != "Errorf" {
+		return annotation{}, false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return annotation{}, false
+	}
+	format, err := strconv.Unquote(lit.Value)
+	if err != nil || !strings.HasSuffix(format, ": %w") {
+		return annotation{}, false
+	}
+
+	name, prefix := strings.TrimSuffix(format, ": %w"), ""
+	if n := strings.LastIndex(name, ": "); n >= 0 {
+		name, prefix = name[n+2:], name[:n+2]
+	}
+	if name == "" || strings.Contains(name, "%") {
+		return annotation{}, false
+	}
+	args := call.Args[1 : len(call.Args)-1]
+	for _, arg := range args {
+		if r.refersTo(arg, b.errObj) {
+			return annotation{}, false
+		}
+	}
+	if prefix == "" {
+		return annotation{name: name}, true
+	}
+	return annotation{name: name, errorf: r.text(call.Fun), format: prefix + "%w", args: args}, true
+}
+
+func calleeIdent(call *ast.CallExpr) *ast.Ident {
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		return fun
+	case *ast.SelectorExpr:
+		return fun.Sel
+	}
+	return nil
+}
+
+// outer returns the outer annotation of a applied to the error err.
+func (r *refactorer) outer(a annotation, err string) string {
+	if a.format == "" {
+		return err
+	}
+	args := []string{strconv.Quote(a.format)}
+	for _, arg := range a.args {
+		args = append(args, r.text(arg))
+	}
+	return fmt.Sprintf("%s(%s, %s)", a.errorf, strings.Join(args, ", "), err)
+}
+
+// annotations returns the annotations of blocks, if they share their outer
+// annotation.
+func (r *refactorer) annotations(blocks []*block) ([]annotation, bool) {
+	annotations := make([]annotation, len(blocks))
+	for n, b := range blocks {
+		a, ok := r.annotation(b)
+		if !ok || n > 0 && r.outer(a, "err") != r.outer(annotations[0], "err") {
+			return nil, false
+		}
+		annotations[n] = a
+	}
+	return annotations, true
+}
+
+// wrapFunc returns the function annotating the errors of b like its
+// return, if it has to be annotated.
+func (r *refactorer) wrapFunc(b *block) string {
+	if b.wrap == nil {
+		return ""
+	}
+	// NewFError(err) becomes NewFError if it takes and returns an error.
+	if call, ok := b.wrap.(*ast.CallExpr); ok && len(call.Args) == 1 && !call.Ellipsis.IsValid() && r.isErr(call.Args[0], b) {
+		if sig, ok := r.pkg.TypesInfo.TypeOf(call.Fun).(*types.Signature); ok && isErrorFunc(sig) {
+			return r.text(call.Fun)
+		}
+	}
+	return fmt.Sprintf("func(err error) error { return %s }", r.renamed(b.wrap, b))
+}
+
+func isErrorFunc(sig *types.Signature) bool {
+	errorType := types.Universe.Lookup("error").Type()
+	return sig.TypeParams().Len() == 0 && !sig.Variadic() &&
+		sig.Params().Len() == 1 && types.Identical(sig.Params().At(0).Type(), errorType) &&
+		sig.Results().Len() == 1 && types.Identical(sig.Results().At(0).Type(), errorType)
+}
+
+func blocks(items []item) []*block {
+	var blocks []*block
+	for _, it := range items {
+		if it.block != nil {
+			blocks = append(blocks, it.block)
+		}
+	}
+	return blocks
+}
+
+func (r *refactorer) classify(call *ast.CallExpr) (kind, string) {
+	info := r.pkg.TypesInfo
+	if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok {
+		if ident, ok := sel.X.(*ast.Ident); ok {
+			if _, ok := info.Uses[ident].(*types.PkgName); ok {
+				return kindChecker, "library"
+			}
+		}
+		if selection := info.Selections[sel]; selection != nil && selection.Kind() == types.MethodVal {
+			return kindReader, "method " + r.text(sel)
+		}
+	}
+	return kindDo, "custom"
+}
+
+// values returns the assigned expressions of the block without its error.
+func (b *block) values() []ast.Expr {
+	if b.assign == nil {
+		return nil
+	}
+	return b.assign.Lhs[:len(b.assign.Lhs)-1]
+}
+
+// defined returns the variable declared by ident in b, if any.
+func (r *refactorer) defined(expr ast.Expr) *types.Var {
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	v, _ := r.pkg.TypesInfo.Defs[ident].(*types.Var)
+	return v
+}
+
+func (r *refactorer) uses(v types.Object) []*ast.Ident {
+	var uses []*ast.Ident
+	for ident, obj := range r.pkg.TypesInfo.Uses {
+		if obj == v {
+			uses = append(uses, ident)
+		}
+	}
+	return uses
+}
+
+// pipeable reports whether the function called by b can be passed to Pipe2.
+func (r *refactorer) pipeable(b *block) bool {
+	if len(b.call.Args) != 1 || b.call.Ellipsis.IsValid() || len(b.values()) != 1 {
+		return false
+	}
+	sig, ok := r.pkg.TypesInfo.TypeOf(b.call.Fun).(*types.Signature)
+	if !ok || sig.TypeParams().Len() > 0 || sig.Variadic() {
+		return false
+	}
+	if _, ok := ast.Unparen(b.call.Fun).(*ast.IndexExpr); ok {
+		return false
+	}
+	return sig.Params().Len() == 1 && sig.Results().Len() == 2
+}
+
+// link reports whether next consumes the value of prev, which is not used
+// anywhere else.
+func (r *refactorer) link(prev, next *block) bool {
+	if !r.pipeable(prev) || !r.pipeable(next) || prev.assign.Tok != token.DEFINE || r.ret(prev, "err") != r.ret(next, "err") {
+		return false
+	}
+	v := r.defined(prev.values()[0])
+	if v == nil {
+		return false
+	}
+	arg, ok := ast.Unparen(next.call.Args[0]).(*ast.Ident)
+	if !ok || r.pkg.TypesInfo.Uses[arg] != v {
+		return false
+	}
+	return len(r.uses(v)) == 1
+}
+
+// chains rewrites runs of linked blocks and returns the remaining items
+// with the rewritten runs replaced by plain statements.
+func (r *refactorer) chains(items []item) []item {
+	var result []item
+	for n := 0; n < len(items); {
+		end := n + 1
+		if items[n].block != nil {
+			for end < len(items) && items[end].block != nil && r.link(items[end-1].block, items[end].block) {
+				end++
+			}
+		}
+		if end-n < 2 {
+			result = append(result, items[n])
+			n++
+			continue
+		}
+
+		r.rewriteChain(items[n:end])
+		result = append(result, item{stmt: items[end-1].block.stmts[0]})
+		n = end
+	}
+	return result
+}
+
+func (r *refactorer) rewriteChain(items []item) {
+	blocks := blocks(items)
+	annotations, shared := r.annotations(blocks)
+	funcs := make([]string, len(blocks))
+	for n, b := range blocks {
+		funcs[n] = r.text(b.call.Fun)
+		switch {
+		case shared && annotations[n].name != "":
+			funcs[n] = fmt.Sprintf("%s(%q, %s)", r.qualified("StepFunc"), annotations[n].name, funcs[n])
+		case !shared && b.wrap != nil:
+			funcs[n] = fmt.Sprintf("%s(%s, %s)", r.qualified("WrapFunc"), r.wrapFunc(b), funcs[n])
+		}
+	}
+	first, last := blocks[0], blocks[len(blocks)-1]
+
+	lhs := make([]string, len(last.assign.Lhs))
+	for n, expr := range last.assign.Lhs {
+		lhs[n] = r.text(expr)
+	}
+	err := last.errName
+	if shared {
+		err = r.outer(annotations[0], err)
+	}
+
+	r.replace(items, []string{
+		fmt.Sprintf("%s %s %s(%s)", strings.Join(lhs, ", "), last.assign.Tok, r.pipe(funcs), r.text(first.call.Args[0])),
+		fmt.Sprintf("if %s != nil {", last.errName),
+		"\t" + r.ret(last, err),
+		"}",
+	})
+	r.report(first.call.Pos(), "%d chained calls can be composed with %s", len(items), r.qualified("Pipe2")+"/Pipe3")
+}
+
+func (r *refactorer) pipe(funcs []string) string {
+	switch len(funcs) {
+	case 2:
+		return fmt.Sprintf("%s(%s)", r.qualified("Pipe2"), strings.Join(funcs, ", "))
+	case 3:
+		return fmt.Sprintf("%s(%s)", r.qualified("Pipe3"), strings.Join(funcs, ", "))
+	default:
+		return fmt.Sprintf("%s(%s, %s)", r.qualified("Pipe2"), r.pipe(funcs[:len(funcs)-1]), funcs[len(funcs)-1])
+	}
+}
+
+// groups rewrites runs of blocks of the same kind.
+func (r *refactorer) groups(items []item) {
+	var (
+		group   []item
+		pending []item
+		blocks  int
+	)
+	flush := func() {
+		if blocks >= 2 {
+			r.rewriteGroup(group)
+		}
+		group, blocks = nil, 0
+	}
+
+	for _, it := range items {
+		if it.block == nil {
+			pending = append(pending, it)
+			continue
+		}
+
+		if blocks > 0 && (group[0].block.key != it.block.key || !r.interleavable(group[0].block.kind, pending)) {
+			flush()
+		}
+		if blocks > 0 {
+			group = append(group, pending...)
+		}
+		group = append(group, it)
+		blocks++
+		pending = nil
+	}
+
+	// Do steps also take the statements using the results of the last
+	// step.
+	if blocks >= 2 && group[0].block.kind == kindDo {
+		last := group[len(group)-1].block
+		for _, it := range pending {
+			if !r.refers(it.stmt, last) {
+				break
+			}
+			group = append(group, it)
+		}
+	}
+	flush()
+}
+
+// interleavable reports whether stmts may stay between the blocks of a
+// group of the given kind.
+func (r *refactorer) interleavable(k kind, items []item) bool {
+	for _, it := range items {
+		if k == kindDo {
+			if escapes(it.stmt) {
+				return false
+			}
+			continue
+		}
+		decl, ok := it.stmt.(*ast.DeclStmt)
+		if !ok {
+			return false
+		}
+		gen, ok := decl.Decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			return false
+		}
+		for _, spec := range gen.Specs {
+			if len(spec.(*ast.ValueSpec).Values) > 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// escapes reports whether stmt leaves the enclosing function or loop, which
+// would change meaning inside a Do step.
+func escapes(stmt ast.Stmt) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt, *ast.BranchStmt, *ast.DeferStmt, *ast.LabeledStmt:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// refers reports whether stmt uses a variable declared by b.
+func (r *refactorer) refers(stmt ast.Stmt, b *block) bool {
+	vars := make(map[types.Object]bool)
+	for _, expr := range b.values() {
+		if v := r.defined(expr); v != nil {
+			vars[v] = true
+		}
+	}
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && vars[r.pkg.TypesInfo.Uses[ident]] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func (r *refactorer) rewriteGroup(items []item) {
+	switch items[0].block.kind {
+	case kindReader:
+		r.rewriteReader(items)
+	case kindChecker:
+		r.rewriteChecker(items)
+	case kindDo:
+		r.rewriteDo(items)
+	}
+}
+
+// unused returns name, or name followed by a number, which is not visible
+// at pos.
+func (r *refactorer) unused(name string, pos token.Pos) string {
+	scope := r.pkg.Types.Scope().Innermost(pos)
+	candidate := name
+	for n := 2; scope != nil; n++ {
+		if _, obj := scope.LookupParent(candidate, pos); obj == nil {
+			break
+		}
+		candidate = fmt.Sprintf("%s%d", name, n)
+	}
+	return candidate
+}
+
+// sticky returns the statement calling method on the sticky value instead
+// of the call checked by b.
+func (r *refactorer) sticky(b *block, call string) string {
+	values := b.values()
+	lhs := make([]string, len(values))
+	blank, declares := true, false
+	for n, expr := range values {
+		lhs[n] = r.text(expr)
+		blank = blank && lhs[n] == "_"
+		declares = declares || r.defined(expr) != nil
+	}
+	if blank {
+		return call
+	}
+
+	tok := token.ASSIGN
+	if b.assign.Tok == token.DEFINE && declares {
+		tok = token.DEFINE
+	}
+	return fmt.Sprintf("%s %s %s", strings.Join(lhs, ", "), tok, call)
+}
+
+func (r *refactorer) args(call *ast.CallExpr) string {
+	args := make([]string, len(call.Args))
+	for n, arg := range call.Args {
+		args[n] = r.text(arg)
+	}
+	result := strings.Join(args, ", ")
+	if call.Ellipsis.IsValid() {
+		result += "..."
+	}
+	return result
+}
+
+// annotated returns the sticky value name annotating the error of b, the
+// n-th block of the rewrite, with Step or Wrap, which apply to the next call
+// only.
+func (r *refactorer) annotated(name string, b *block, annotations []annotation, shared bool, n int) string {
+	switch {
+	case shared && annotations[n].name != "":
+		return fmt.Sprintf("%s.Step(%q)", name, annotations[n].name)
+	case !shared && b.wrap != nil:
+		return fmt.Sprintf("%s.Wrap(%s)", name, r.wrapFunc(b))
+	}
+	return name
+}
+
+// errCheck returns the check of the sticky error of name returning err as
+// the error result of the return of b.
+func (r *refactorer) errCheck(name string, b *block, err string) []string {
+	return []string{
+		fmt.Sprintf("if err := %s.Err(); err != nil {", name),
+		"\t" + r.ret(b, err),
+		"}",
+	}
+}
+
+func (r *refactorer) lines(items []item, wrap func(int, *block) string) []string {
+	var lines []string
+	n := 0
+	for _, it := range items {
+		if it.block == nil {
+			lines = append(lines, r.text(it.stmt))
+			continue
+		}
+		lines = append(lines, r.sticky(it.block, wrap(n, it.block)))
+		n++
+	}
+	return lines
+}
+
+func (r *refactorer) rewriteReader(items []item) {
+	first := items[0].block
+	sel := ast.Unparen(first.call.Fun).(*ast.SelectorExpr)
+	recv := r.pkg.TypesInfo.TypeOf(sel.X)
+
+	named := recv
+	if ptr, ok := named.(*types.Pointer); ok {
+		named = ptr.Elem()
+	}
+	typeName := "Value"
+	if n, ok := named.(*types.Named); ok {
+		typeName = n.Obj().Name()
+	}
+
+	wrapper := "Error" + typeName
+	name := r.unused("error"+typeName, first.stmts[0].Pos())
+	lines := []string{fmt.Sprintf("%s := New%s(%s)", name, wrapper, r.text(sel.X))}
+	annotations, shared := r.annotations(blocks(items))
+	lines = append(lines, r.lines(items, func(n int, b *block) string {
+		return fmt.Sprintf("%s.%s(%s)", r.annotated(name, b, annotations, shared, n), sel.Sel.Name, r.args(b.call))
+	})...)
+	err := "err"
+	if shared {
+		err = r.outer(annotations[0], err)
+	}
+	lines = append(lines, r.errCheck(name, first, err)...)
+	r.replace(items, lines)
+
+	typeRef := types.TypeString(recv, func(p *types.Package) string { return p.Path() })
+	r.report(first.call.Pos(), "%d calls of %s can share one %s, generate it with wrappergen -type %s -methods %s",
+		len(blocks(items)), r.text(sel), wrapper, typeRef, sel.Sel.Name)
+}
+
+func (r *refactorer) rewriteChecker(items []item) {
+	first := items[0].block
+	name := r.unused("checker", first.stmts[0].Pos())
+	annotations, shared := r.annotations(blocks(items))
+
+	var paths []string
+	seen := make(map[string]bool)
+	lines := []string{fmt.Sprintf("%s := NewErrorChecker()", name)}
+	lines = append(lines, r.lines(items, func(n int, b *block) string {
+		sel := ast.Unparen(b.call.Fun).(*ast.SelectorExpr)
+		pkg := r.pkg.TypesInfo.Uses[sel.X.(*ast.Ident)].(*types.PkgName).Imported()
+		if !seen[pkg.Path()] {
+			seen[pkg.Path()] = true
+			paths = append(paths, pkg.Path())
+		}
+		return fmt.Sprintf("%s.%s%s(%s)", r.annotated(name, b, annotations, shared, n), capitalize(pkg.Name()), sel.Sel.Name, r.args(b.call))
+	})...)
+	err := "err"
+	if shared {
+		err = r.outer(annotations[0], err)
+	}
+	lines = append(lines, r.errCheck(name, first, err)...)
+	r.replace(items, lines)
+
+	r.report(first.call.Pos(), "%d library calls can share an ErrorChecker, generate it with checkergen %s",
+		len(blocks(items)), strings.Join(paths, " "))
+}
+
+func capitalize(s string) string {
+	c, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(c)) + s[size:]
+}
+
+// step is a block and the statements following it up to the next block.
+type step struct {
+	block *block
+	stmts []ast.Stmt
+}
+
+func (r *refactorer) rewriteDo(items []item) {
+	var steps []*step
+	for _, it := range items {
+		if it.block != nil {
+			steps = append(steps, &step{block: it.block})
+		} else {
+			last := steps[len(steps)-1]
+			last.stmts = append(last.stmts, it.stmt)
+		}
+	}
+
+	// Variables used outside of the step declaring them have to be
+	// declared before Do.
+	var hoisted []string
+	hoist := make(map[*step]bool)
+	for _, s := range steps {
+		for _, expr := range s.block.values() {
+			v := r.defined(expr)
+			if v == nil || !r.usedOutside(v, s) {
+				continue
+			}
+			hoist[s] = true
+		}
+		if !hoist[s] {
+			continue
+		}
+		for _, expr := range s.block.values() {
+			if v := r.defined(expr); v != nil {
+				hoisted = append(hoisted, fmt.Sprintf("var %s %s", v.Name(), types.TypeString(v.Type(), types.RelativeTo(r.pkg.Types))))
+			}
+		}
+	}
+	for _, s := range steps {
+		for _, stmt := range s.stmts {
+			if r.declaresUsedOutside(stmt, s) {
+				r.report(s.block.call.Pos(), "calls could be passed to %s, but statements between them declare variables used later", r.qualified("Do"))
+				return
+			}
+		}
+	}
+
+	first := steps[0].block
+	annotations, shared := r.annotations(blocks(items))
+	err := "err"
+	if shared {
+		err = r.outer(annotations[0], err)
+	}
+	lines := append([]string(nil), hoisted...)
+	lines = append(lines, fmt.Sprintf("if err := %s(", r.qualified("Do")))
+	for n, s := range steps {
+		// A shared outer annotation wraps the error of Do, the rest of
+		// the annotation names the step.
+		name, failed := "", s.block.errName
+		switch {
+		case shared:
+			name = annotations[n].name
+		case s.block.wrap != nil:
+			failed = r.text(s.block.wrap)
+		}
+		lines = append(lines, r.stepLines(s, hoist[s], name, failed)...)
+	}
+	lines = append(lines, "); err != nil {", "\t"+r.ret(first, err), "}")
+	r.replace(items, lines)
+
+	r.report(first.call.Pos(), "%d custom calls can be passed as steps to %s", len(steps), r.qualified("Do"))
+}
+
+// stepLines returns the step of s named name, if not empty, returning
+// failed when its call fails.
+func (r *refactorer) stepLines(s *step, hoisted bool, name, failed string) []string {
+	b := s.block
+	open, end := "func() error {", "},"
+	if name != "" {
+		open, end = fmt.Sprintf("%s(%q, %s", r.qualified("Step"), name, open), "}),"
+	}
+
+	// A call returning only an error without arguments is a step by
+	// itself.
+	if len(b.values()) == 0 && len(s.stmts) == 0 && failed == b.errName {
+		if len(b.call.Args) > 0 {
+			return []string{"\t" + open, "\t\treturn " + r.text(b.call), "\t" + end}
+		}
+		if name != "" {
+			return []string{fmt.Sprintf("\t%s(%q, %s),", r.qualified("Step"), name, r.text(b.call.Fun))}
+		}
+		return []string{"\t" + r.text(b.call.Fun) + ","}
+	}
+
+	assign := r.text(b.assign)
+	if hoisted {
+		assign = strings.Replace(assign, ":=", "=", 1)
+		open = strings.Replace(open, "func() error", "func() ("+b.errName+" error)", 1)
+	}
+
+	lines := []string{"\t" + open, "\t\t" + assign}
+	if len(s.stmts) == 0 && failed == b.errName {
+		return append(lines, "\t\treturn "+b.errName, "\t"+end)
+	}
+	// The statements following the call only run when it succeeds.
+	lines = append(lines, fmt.Sprintf("\t\tif %s != nil {", b.errName), "\t\t\treturn "+failed, "\t\t}")
+	for _, stmt := range s.stmts {
+		lines = append(lines, "\t\t"+strings.ReplaceAll(r.text(stmt), "\n", "\n\t"))
+	}
+	return append(lines, "\t\treturn nil", "\t"+end)
+}
+
+// usedOutside reports whether v is used outside the statements of s.
+func (r *refactorer) usedOutside(v *types.Var, s *step) bool {
+	for _, ident := range r.uses(v) {
+		if !s.contains(ident.Pos()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *refactorer) declaresUsedOutside(stmt ast.Stmt, s *step) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return !found
+		}
+		if v, ok := r.pkg.TypesInfo.Defs[ident].(*types.Var); ok && r.usedOutside(v, s) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func (s *step) contains(pos token.Pos) bool {
+	for _, stmt := range append(append([]ast.Stmt(nil), s.block.stmts...), s.stmts...) {
+		if stmt.Pos() <= pos && pos < stmt.End() {
+			return true
+		}
+	}
+	return false
+}
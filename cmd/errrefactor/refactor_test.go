@@ -0,0 +1,317 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// rewriteSource rewrites src as the only file of a package and returns the
+// rewritten source, or src if nothing is suggested.
+func rewriteSource(t *testing.T, src string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/a\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := packages.Load(&packages.Config{Mode: loadMode, Dir: dir}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 || len(pkgs[0].Errors) > 0 {
+		t.Fatalf("loading the package: %v", pkgs[0].Errors)
+	}
+
+	_, out, _, err := rewrite(pkgs[0], pkgs[0].Syntax[0], defaultLib)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out == nil {
+		return src
+	}
+	return string(out)
+}
+
+var rewriteTests = []struct {
+	name string
+	src  string
+	want string
+}{
+	{
+		name: "do steps stop at a failed call",
+		src: `package a
+
+func first() (int, error)  { return 0, nil }
+func second() (int, error) { return 0, nil }
+func use(int)              {}
+
+func run() error {
+	x, err := first()
+	if err != nil {
+		return err
+	}
+	use(x)
+	use(x)
+
+	y, err := second()
+	if err != nil {
+		return err
+	}
+	use(y)
+	return nil
+}
+`,
+		want: `package a
+
+import "github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+
+func first() (int, error)  { return 0, nil }
+func second() (int, error) { return 0, nil }
+func use(int)              {}
+
+func run() error {
+	if err := errorhandling.Do(
+		func() error {
+			x, err := first()
+			if err != nil {
+				return err
+			}
+			use(x)
+			use(x)
+			return nil
+		},
+		func() error {
+			y, err := second()
+			if err != nil {
+				return err
+			}
+			use(y)
+			return nil
+		},
+	); err != nil {
+		return err
+	}
+	return nil
+}
+`,
+	},
+	{
+		name: "do steps keep annotations they do not share",
+		src: `package a
+
+import "fmt"
+
+func down() error { return nil }
+func up() error   { return nil }
+
+func run() error {
+	if err := down(); err != nil {
+		return fmt.Errorf("down: %w", err)
+	}
+	if err := up(); err != nil {
+		return fmt.Errorf("%s: %w", "up", err)
+	}
+	return nil
+}
+`,
+		want: `package a
+
+import (
+	"fmt"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+func down() error { return nil }
+func up() error   { return nil }
+
+func run() error {
+	if err := errorhandling.Do(
+		func() error {
+			err := down()
+			if err != nil {
+				return fmt.Errorf("down: %w", err)
+			}
+			return nil
+		},
+		func() error {
+			err := up()
+			if err != nil {
+				return fmt.Errorf("%s: %w", "up", err)
+			}
+			return nil
+		},
+	); err != nil {
+		return err
+	}
+	return nil
+}
+`,
+	},
+	{
+		name: "chain names its steps and keeps the shared annotation",
+		src: `package a
+
+import "fmt"
+
+func read(name string) (string, error) { return name, nil }
+func parse(s string) (int, error)      { return len(s), nil }
+
+func load(name string) (int, error) {
+	s, err := read(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: read: %w", name, err)
+	}
+
+	n, err := parse(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: parse: %w", name, err)
+	}
+	return n, nil
+}
+`,
+		want: `package a
+
+import (
+	"fmt"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+func read(name string) (string, error) { return name, nil }
+func parse(s string) (int, error)      { return len(s), nil }
+
+func load(name string) (int, error) {
+	n, err := errorhandling.Pipe2(errorhandling.StepFunc("read", read), errorhandling.StepFunc("parse", parse))(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return n, nil
+}
+`,
+	},
+	{
+		name: "checker wraps the call of the annotated error",
+		src: `package a
+
+import (
+	"errors"
+	"strconv"
+)
+
+var errVersion = errors.New("version")
+
+func versionError(err error) error { return errors.Join(errVersion, err) }
+
+func parse(a, b string) (int, int, error) {
+	x, e := strconv.Atoi(a)
+	if e != nil {
+		return 0, 0, versionError(e)
+	}
+	y, e := strconv.Atoi(b)
+	if e != nil {
+		return 0, 0, e
+	}
+	return x, y, nil
+}
+`,
+		want: `package a
+
+import (
+	"errors"
+	"strconv"
+)
+
+var errVersion = errors.New("version")
+
+func versionError(err error) error { return errors.Join(errVersion, err) }
+
+func parse(a, b string) (int, int, error) {
+	checker := NewErrorChecker()
+	x := checker.Wrap(versionError).StrconvAtoi(a)
+	y := checker.StrconvAtoi(b)
+	if err := checker.Err(); err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
+`,
+	},
+	{
+		name: "reader renames only the error variable",
+		src: `package a
+
+import (
+	"bufio"
+	"fmt"
+)
+
+func lines(reader *bufio.Reader) (string, string, error) {
+	first, e := reader.ReadString('\n')
+	if e != nil {
+		return "", "", fmt.Errorf("line %d: %w", 1, e)
+	}
+	second, e := reader.ReadString('\n')
+	if e != nil {
+		return "", "", fmt.Errorf("second: %w", e)
+	}
+	return first, second, nil
+}
+`,
+		want: `package a
+
+import (
+	"bufio"
+	"fmt"
+)
+
+func lines(reader *bufio.Reader) (string, string, error) {
+	errorReader := NewErrorReader(reader)
+	first := errorReader.Wrap(func(err error) error { return fmt.Errorf("line %d: %w", 1, err) }).ReadString('\n')
+	second := errorReader.Wrap(func(err error) error { return fmt.Errorf("second: %w", err) }).ReadString('\n')
+	if err := errorReader.Err(); err != nil {
+		return "", "", err
+	}
+	return first, second, nil
+}
+`,
+	},
+}
+
+func TestRewrite(t *testing.T) {
+	for _, test := range rewriteTests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := rewriteSource(t, test.src); got != test.want {
+				t.Errorf("got\n%s\nwant\n%s\ndiff\n%s", got, test.want, unifiedDiff("a.go", []byte(test.want), []byte(got)))
+			}
+		})
+	}
+}
+
+func TestRewriteUnchecked(t *testing.T) {
+	// The error is dropped rather than returned, no pattern applies.
+	src := `package a
+
+func first() error  { return nil }
+func second() error { return nil }
+
+func run() {
+	if err := first(); err != nil {
+		return
+	}
+	if err := second(); err != nil {
+		return
+	}
+}
+`
+	if got := rewriteSource(t, src); got != src {
+		t.Errorf("rewrote a block without an error result:\n%s", got)
+	}
+}
@@ -0,0 +1,70 @@
+// Command errstat measures how much of a Go source tree is spent on error
+// handling, reproducing the most common words statistic of the slides.
+//
+// It walks the given directories, skipping vendor, testdata and hidden ones,
+// and reports
+//
+//   - words: the most frequent identifiers and keywords,
+//   - functions: the share of lines spent on error checks per function,
+//   - patterns: functions which are candidates for the error in struct,
+//     check, monad, generic monad and go2 patterns.
+//
+// Files are only parsed, not type checked, so any tree can be measured
+// whether its dependencies are available or not.
+//
+// Usage:
+//
+//	errstat [-report words|functions|patterns|all] [-format text|csv|svg] [dirs]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var (
+		report   = flag.String("report", "all", "report to print: words, functions, patterns or all; svg charts words for all")
+		format   = flag.String("format", "text", "output format: text, csv or svg")
+		top      = flag.Int("top", 20, "number of words and functions to print, 0 prints all")
+		minCheck = flag.Int("min", 2, "minimum number of error checks of a function to be reported")
+		tests    = flag.Bool("tests", false, "include _test.go files")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: errstat [-report words|functions|patterns|all] [-format text|csv|svg] [dirs]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	write, ok := writers[*format]
+	if !ok {
+		fatal(fmt.Errorf("unknown format %q", *format))
+	}
+	switch *report {
+	case "words", "functions", "patterns", "all":
+	default:
+		fatal(fmt.Errorf("unknown report %q", *report))
+	}
+
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	s := newStats(*minCheck)
+	for _, dir := range dirs {
+		if err := s.walk(dir, *tests); err != nil {
+			fatal(err)
+		}
+	}
+
+	if err := write(os.Stdout, s.summary(*top), *report); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "errstat: %v\n", err)
+	os.Exit(1)
+}
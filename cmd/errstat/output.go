@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type writer func(w io.Writer, s *summary, report string) error
+
+var writers = map[string]writer{
+	"text": writeText,
+	"csv":  writeCSV,
+	"svg":  writeSVG,
+}
+
+func percent(part, whole int) string {
+	if whole == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(whole))
+}
+
+func writeText(w io.Writer, s *summary, report string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "%d files, %d lines, %s of them checking errors\n", s.Files, s.Lines, percent(s.CheckLines, s.Lines))
+
+	if report == "words" || report == "all" {
+		fmt.Fprintf(tw, "\nword\tcount\tshare\n")
+		for _, word := range s.Words {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", word.Text, word.Count, percent(word.Count, s.Tokens))
+		}
+	}
+
+	if report == "functions" || report == "all" {
+		fmt.Fprintf(tw, "\nfunction\tlines\tchecks\tcheck lines\tshare\tpatterns\n")
+		for _, fn := range s.Functions {
+			fmt.Fprintf(tw, "%s %s\t%d\t%d\t%d\t%s\t%s\n", fn.Pos, fn.Name, fn.Lines, fn.Checks, fn.CheckLines, percent(fn.CheckLines, fn.Lines), strings.Join(fn.Patterns, ", "))
+		}
+	}
+
+	if report == "patterns" || report == "all" {
+		fmt.Fprintf(tw, "\npattern\tcandidates\tfunctions\n")
+		for _, pattern := range patterns {
+			names := make([]string, len(s.Patterns[pattern]))
+			for n, fn := range s.Patterns[pattern] {
+				names[n] = fn.Name
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", pattern, len(names), strings.Join(names, ", "))
+		}
+	}
+	return tw.Flush()
+}
+
+// writeCSV writes one table per report, separated by an empty line.
+func writeCSV(w io.Writer, s *summary, report string) error {
+	cw := csv.NewWriter(w)
+	tables := 0
+	table := func(header []string) {
+		if tables > 0 {
+			cw.Flush()
+			io.WriteString(w, "\n")
+		}
+		tables++
+		cw.Write(header)
+	}
+
+	if report == "words" || report == "all" {
+		table([]string{"word", "count", "share"})
+		for _, word := range s.Words {
+			cw.Write([]string{word.Text, strconv.Itoa(word.Count), formatShare(word.Count, s.Tokens)})
+		}
+	}
+
+	if report == "functions" || report == "all" {
+		table([]string{"file", "line", "function", "lines", "checks", "check lines", "share", "patterns"})
+		for _, fn := range s.Functions {
+			cw.Write([]string{
+				fn.Pos.Filename,
+				strconv.Itoa(fn.Pos.Line),
+				fn.Name,
+				strconv.Itoa(fn.Lines),
+				strconv.Itoa(fn.Checks),
+				strconv.Itoa(fn.CheckLines),
+				formatShare(fn.CheckLines, fn.Lines),
+				strings.Join(fn.Patterns, ";"),
+			})
+		}
+	}
+
+	if report == "patterns" || report == "all" {
+		table([]string{"pattern", "file", "line", "function"})
+		for _, pattern := range patterns {
+			for _, fn := range s.Patterns[pattern] {
+				cw.Write([]string{pattern, fn.Pos.Filename, strconv.Itoa(fn.Pos.Line), fn.Name})
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatShare(part, whole int) string {
+	if whole == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(float64(part)/float64(whole), 'f', 4, 64)
+}
+
+type bar struct {
+	label string
+	value float64
+	text  string
+}
+
+// writeSVG writes a horizontal bar chart of a single report, the words for
+// all.
+func writeSVG(w io.Writer, s *summary, report string) error {
+	var (
+		title string
+		bars  []bar
+	)
+	switch report {
+	case "words", "all":
+		title = "Most common words"
+		for _, word := range s.Words {
+			bars = append(bars, bar{label: word.Text, value: float64(word.Count), text: strconv.Itoa(word.Count)})
+		}
+	case "functions":
+		title = "Share of lines checking errors"
+		for _, fn := range s.Functions {
+			bars = append(bars, bar{label: fn.Name, value: fn.Share(), text: percent(fn.CheckLines, fn.Lines)})
+		}
+	case "patterns":
+		title = "Candidates per pattern"
+		for _, pattern := range patterns {
+			count := len(s.Patterns[pattern])
+			bars = append(bars, bar{label: pattern, value: float64(count), text: strconv.Itoa(count)})
+		}
+	}
+	return chart(w, title, bars)
+}
+
+func chart(w io.Writer, title string, bars []bar) error {
+	const (
+		barHeight = 20
+		gap       = 6
+		top       = 40
+		width     = 800
+		label     = 200
+		maxBar    = width - label - 80
+	)
+
+	max := 0.0
+	for _, b := range bars {
+		if b.value > max {
+			max = b.value
+		}
+	}
+	height := top + len(bars)*(barHeight+gap) + gap
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", width, height)
+	fmt.Fprintf(&sb, `<text x="%d" y="24" font-size="16" text-anchor="middle">%s</text>`+"\n", width/2, html.EscapeString(title))
+	for n, b := range bars {
+		y := top + n*(barHeight+gap)
+		length := 0
+		if max > 0 {
+			length = int(b.value / max * maxBar)
+		}
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", label-8, y+barHeight-6, html.EscapeString(b.label))
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="#4a7fb5"/>`+"\n", label, y, length, barHeight)
+		fmt.Fprintf(&sb, `<text x="%d" y="%d">%s</text>`+"\n", label+length+6, y+barHeight-6, html.EscapeString(b.text))
+	}
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
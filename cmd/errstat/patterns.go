@@ -0,0 +1,181 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// checked is a call whose error is checked right away:
+//
+//	x, err := f()
+//	if err != nil {
+//		...
+//	}
+//
+// or
+//
+//	if err := f(); err != nil {
+//		...
+//	}
+type checked struct {
+	assign *ast.AssignStmt
+	call   *ast.CallExpr
+}
+
+// values returns the assigned expressions without the error.
+func (c checked) values() []ast.Expr {
+	return c.assign.Lhs[:len(c.assign.Lhs)-1]
+}
+
+// candidates returns the patterns of the slides which could shorten the
+// error handling of decl. Only the syntax is known, so a selector is a
+// library call when its operand is the name of an import and a method call
+// otherwise, and any other call is a call of a custom function, a step for
+// Do like errrefactor proposes.
+func candidates(decl *ast.FuncDecl, imports map[string]bool, checks, minChecks int) []string {
+	var (
+		methods  = make(map[string]int)
+		library  int
+		custom   int
+		maxChain int
+	)
+	each(decl.Body, func(list []ast.Stmt) {
+		var prev *checked
+		chain := 1
+		for n := range list {
+			c := checkedAt(list, n)
+			if c == nil {
+				continue
+			}
+
+			if sel, ok := ast.Unparen(c.call.Fun).(*ast.SelectorExpr); ok {
+				if ident, ok := sel.X.(*ast.Ident); ok && imports[ident.Name] {
+					library++
+				} else {
+					methods[operand(sel.X)+"."+sel.Sel.Name]++
+				}
+			} else {
+				custom++
+			}
+
+			if prev != nil && consumes(*c, *prev) {
+				chain++
+			} else {
+				chain = 1
+			}
+			if chain > maxChain {
+				maxChain = chain
+			}
+			prev = c
+		}
+	})
+
+	var result []string
+	for _, count := range methods {
+		if count >= minChecks {
+			result = append(result, patternErrorInStruct)
+			break
+		}
+	}
+	if library >= minChecks {
+		result = append(result, patternCheck)
+	}
+	if custom >= minChecks {
+		result = append(result, patternMonad)
+	}
+	if maxChain >= 2 && maxChain >= minChecks {
+		result = append(result, patternGenericMonad)
+	}
+	if checks >= minChecks && returnsError(decl) {
+		result = append(result, patternGo2)
+	}
+	return result
+}
+
+// operand returns the source of a simple operand expression.
+func operand(expr ast.Expr) string {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return operand(expr.X) + "." + expr.Sel.Name
+	case *ast.CallExpr:
+		return operand(expr.Fun) + "()"
+	default:
+		return "?"
+	}
+}
+
+// each calls f with every statement list in body.
+func each(body *ast.BlockStmt, f func([]ast.Stmt)) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			f(n.List)
+		case *ast.CaseClause:
+			f(n.Body)
+		case *ast.CommClause:
+			f(n.Body)
+		}
+		return true
+	})
+}
+
+func checkedAt(list []ast.Stmt, n int) *checked {
+	if stmt, ok := list[n].(*ast.IfStmt); ok && stmt.Init != nil {
+		assign, ok := stmt.Init.(*ast.AssignStmt)
+		if !ok {
+			return nil
+		}
+		return newChecked(assign, stmt)
+	}
+
+	assign, ok := list[n].(*ast.AssignStmt)
+	if !ok || n+1 == len(list) {
+		return nil
+	}
+	stmt, ok := list[n+1].(*ast.IfStmt)
+	if !ok || stmt.Init != nil {
+		return nil
+	}
+	return newChecked(assign, stmt)
+}
+
+func newChecked(assign *ast.AssignStmt, stmt *ast.IfStmt) *checked {
+	if assign.Tok != token.DEFINE && assign.Tok != token.ASSIGN || len(assign.Rhs) != 1 {
+		return nil
+	}
+	call, ok := ast.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+	if !ok {
+		return nil
+	}
+	errIdent, ok := assign.Lhs[len(assign.Lhs)-1].(*ast.Ident)
+	if !ok || !isErrorName(errIdent) {
+		return nil
+	}
+	cond, ok := stmt.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.NEQ || !isIdent(cond.X, errIdent.Name) || !isIdent(cond.Y, "nil") {
+		return nil
+	}
+	return &checked{assign: assign, call: call}
+}
+
+// consumes reports whether the call of next is given the single value of
+// prev.
+func consumes(next, prev checked) bool {
+	values := prev.values()
+	if len(values) != 1 || len(next.call.Args) != 1 {
+		return false
+	}
+	value, ok := values[0].(*ast.Ident)
+	return ok && isIdent(next.call.Args[0], value.Name)
+}
+
+// returnsError reports whether the last result of decl is an error.
+func returnsError(decl *ast.FuncDecl) bool {
+	results := decl.Type.Results
+	if results == nil || len(results.List) == 0 {
+		return false
+	}
+	return isIdent(results.List[len(results.List)-1].Type, "error")
+}
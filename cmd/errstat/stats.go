@@ -0,0 +1,262 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Patterns of the slides in the order they are presented.
+const (
+	patternErrorInStruct = "error in struct"
+	patternCheck         = "check"
+	patternMonad         = "monad"
+	patternGenericMonad  = "generic monad"
+	patternGo2           = "go2"
+)
+
+var patterns = []string{patternErrorInStruct, patternCheck, patternMonad, patternGenericMonad, patternGo2}
+
+type word struct {
+	Text  string
+	Count int
+}
+
+type function struct {
+	Pos        token.Position
+	Name       string
+	Lines      int
+	CheckLines int
+	Checks     int
+	Patterns   []string
+}
+
+// Share returns the fraction of the lines of the function spent on error
+// checks.
+func (f *function) Share() float64 {
+	if f.Lines == 0 {
+		return 0
+	}
+	return float64(f.CheckLines) / float64(f.Lines)
+}
+
+type summary struct {
+	Files      int
+	Lines      int
+	CheckLines int
+	Tokens     int
+	Words      []word
+	Functions  []*function
+	// Patterns maps every pattern to its candidate functions.
+	Patterns map[string][]*function
+}
+
+type stats struct {
+	fset      *token.FileSet
+	minChecks int
+	files     int
+	lines     int
+	tokens    int
+	words     map[string]int
+	functions []*function
+}
+
+func newStats(minChecks int) *stats {
+	return &stats{
+		fset:      token.NewFileSet(),
+		minChecks: minChecks,
+		words:     make(map[string]int),
+	}
+}
+
+func (s *stats) walk(root string, tests bool) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") || !tests && strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+		return s.file(path)
+	})
+}
+
+func (s *stats) file(filename string) error {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	file, err := parser.ParseFile(s.fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+	s.files++
+	s.lines += s.fset.File(file.Pos()).LineCount()
+	s.count(src)
+
+	imports := make(map[string]bool)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = true
+	}
+
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			s.function(fn, imports)
+		}
+	}
+	return nil
+}
+
+// count counts the identifiers and keywords of src.
+func (s *stats) count(src []byte) {
+	var sc scanner.Scanner
+	file := token.NewFileSet().AddFile("", -1, len(src))
+	sc.Init(file, src, nil, 0)
+	for {
+		_, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			return
+		}
+		switch {
+		case tok == token.IDENT:
+			s.words[lit]++
+		case tok.IsKeyword():
+			s.words[tok.String()]++
+		case tok == token.SEMICOLON && lit == "\n":
+			// Automatically inserted.
+			continue
+		}
+		s.tokens++
+	}
+}
+
+func (s *stats) function(decl *ast.FuncDecl, imports map[string]bool) {
+	fn := &function{
+		Pos:   s.fset.Position(decl.Pos()),
+		Name:  funcName(decl),
+		Lines: s.fset.Position(decl.End()).Line - s.fset.Position(decl.Pos()).Line + 1,
+	}
+
+	checkLines := make(map[int]bool)
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		stmt, ok := n.(*ast.IfStmt)
+		if !ok || !checksError(stmt.Cond) {
+			return true
+		}
+		fn.Checks++
+		start := s.fset.Position(stmt.Pos()).Line
+		end := s.fset.Position(stmt.Body.End()).Line
+		for line := start; line <= end; line++ {
+			checkLines[line] = true
+		}
+		return true
+	})
+	fn.CheckLines = len(checkLines)
+	fn.Patterns = candidates(decl, imports, fn.Checks, s.minChecks)
+	s.functions = append(s.functions, fn)
+}
+
+func funcName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return decl.Name.Name
+	}
+	recv := decl.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	switch index := recv.(type) {
+	case *ast.IndexExpr:
+		recv = index.X
+	case *ast.IndexListExpr:
+		recv = index.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + decl.Name.Name
+	}
+	return decl.Name.Name
+}
+
+// checksError reports whether cond compares an error variable, one named
+// err or ending with Err, to nil.
+func checksError(cond ast.Expr) bool {
+	found := false
+	ast.Inspect(cond, func(n ast.Node) bool {
+		binary, ok := n.(*ast.BinaryExpr)
+		if !ok || binary.Op != token.NEQ {
+			return !found
+		}
+		if isErrorName(binary.X) && isIdent(binary.Y, "nil") || isErrorName(binary.Y) && isIdent(binary.X, "nil") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func isErrorName(expr ast.Expr) bool {
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	return ok && (ident.Name == "err" || strings.HasSuffix(ident.Name, "Err") || strings.HasSuffix(ident.Name, "err"))
+}
+
+func isIdent(expr ast.Expr, name string) bool {
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	return ok && ident.Name == name
+}
+
+func (s *stats) summary(top int) *summary {
+	sum := &summary{
+		Files:    s.files,
+		Lines:    s.lines,
+		Tokens:   s.tokens,
+		Patterns: make(map[string][]*function),
+	}
+
+	for text, count := range s.words {
+		sum.Words = append(sum.Words, word{Text: text, Count: count})
+	}
+	sort.Slice(sum.Words, func(i, j int) bool {
+		if sum.Words[i].Count != sum.Words[j].Count {
+			return sum.Words[i].Count > sum.Words[j].Count
+		}
+		return sum.Words[i].Text < sum.Words[j].Text
+	})
+	if top > 0 && len(sum.Words) > top {
+		sum.Words = sum.Words[:top]
+	}
+
+	for _, fn := range s.functions {
+		sum.CheckLines += fn.CheckLines
+		for _, pattern := range fn.Patterns {
+			sum.Patterns[pattern] = append(sum.Patterns[pattern], fn)
+		}
+		if fn.Checks >= s.minChecks {
+			sum.Functions = append(sum.Functions, fn)
+		}
+	}
+	sort.SliceStable(sum.Functions, func(i, j int) bool {
+		return sum.Functions[i].Share() > sum.Functions[j].Share()
+	})
+	if top > 0 && len(sum.Functions) > top {
+		sum.Functions = sum.Functions[:top]
+	}
+	return sum
+}
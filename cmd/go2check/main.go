@@ -0,0 +1,75 @@
+// Command go2check translates Go files written with the check expression of
+// the Go 2 error handling draft design into Go 1.
+//
+//	rawConfiguration := check readConfiguration(filename)
+//	header, _ := check reader.ReadLine()
+//	check json.Unmarshal(configuration.Body, &data)
+//
+// becomes an assignment followed by an explicit
+//
+//	if err != nil {
+//		return zero values..., err
+//	}
+//
+// check may also be used inside expressions, in which case the checked call
+// is evaluated right before the statement containing it. The output keeps
+// //line directives so that compiler errors point back to the .go2 file.
+//
+// Usage:
+//
+//	go2check [-o output.go] file.go2...
+//
+// Without -o, file.go2 is translated to file.go.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const generator = "go2check"
+
+func main() {
+	output := flag.String("o", "", "output file; only valid with a single input file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-o output.go] file.go2...\n", generator)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || *output != "" && flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, filename := range flag.Args() {
+		target := *output
+		if target == "" {
+			target = strings.TrimSuffix(filename, ".go2") + ".go"
+		}
+		if err := translateFile(filename, target); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func translateFile(filename, target string) error {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	out, err := Translate(filename, src)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(target, out, 0o644)
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// checkMarker replaces the check keyword so that go/parser accepts the draft
+// syntax. It has the same length as "check" so positions are not shifted.
+const checkMarker = "<-   "
+
+type translator struct {
+	name         string
+	fset         *token.FileSet
+	file         *token.File
+	src          []byte
+	checks       map[token.Pos]bool
+	consumed     map[token.Pos]bool
+	replacements []*replacement
+	errors       scanner.ErrorList
+	counter      int
+}
+
+// replacement substitutes the source between pos and end.
+type replacement struct {
+	pos, end token.Pos
+	render   func() string
+}
+
+// Translate returns the Go 1 equivalent of src. Line directives refer to
+// the original file as name.
+func Translate(name string, src []byte) ([]byte, error) {
+	marked, offsets := markChecks(src)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, name, marked, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &translator{
+		name:     name,
+		fset:     fset,
+		file:     fset.File(f.Pos()),
+		src:      src,
+		checks:   make(map[token.Pos]bool),
+		consumed: make(map[token.Pos]bool),
+	}
+	for _, offset := range offsets {
+		t.checks[t.file.Pos(offset)] = true
+	}
+
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			t.function(fn.Type, fn.Body)
+		}
+	}
+	for pos := range t.checks {
+		if !t.consumed[pos] {
+			t.errorf(pos, "check is only allowed in statements of a function body, not in loop conditions, else if or the right operand of && and ||")
+		}
+	}
+	if len(t.errors) > 0 {
+		t.errors.Sort()
+		return nil, t.errors.Err()
+	}
+
+	sort.Slice(t.replacements, func(i, j int) bool {
+		a, b := t.replacements[i], t.replacements[j]
+		return a.pos < b.pos || a.pos == b.pos && a.end > b.end
+	})
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "// Code generated by %s from %s; DO NOT EDIT.\n\n", generator, name)
+	fmt.Fprintf(&out, "//line %s:1\n", name)
+	out.WriteString(t.render(t.file.Pos(0), t.file.Pos(t.file.Size()), nil))
+	return []byte(out.String()), nil
+}
+
+// markChecks replaces every check keyword followed by an operand with
+// checkMarker and returns the offsets of the replaced keywords.
+func markChecks(src []byte) ([]byte, []int) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+
+	type item struct {
+		offset int
+		tok    token.Token
+		lit    string
+	}
+	var items []item
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		items = append(items, item{file.Offset(pos), tok, lit})
+	}
+
+	marked := append([]byte(nil), src...)
+	var offsets []int
+	for n, it := range items {
+		if it.tok != token.IDENT || it.lit != "check" || n+1 == len(items) {
+			continue
+		}
+		if n > 0 && items[n-1].tok == token.PERIOD {
+			continue
+		}
+		switch items[n+1].tok {
+		case token.IDENT, token.INT, token.FLOAT, token.IMAG, token.CHAR, token.STRING,
+			token.MUL, token.AND, token.LBRACK, token.FUNC, token.MAP, token.CHAN, token.STRUCT, token.ARROW:
+			copy(marked[it.offset:], checkMarker)
+			offsets = append(offsets, it.offset)
+		}
+	}
+	return marked, offsets
+}
+
+func (t *translator) errorf(pos token.Pos, format string, args ...interface{}) {
+	t.errors.Add(t.fset.Position(pos), fmt.Sprintf(format, args...))
+}
+
+func (t *translator) isCheck(n ast.Node) bool {
+	u, ok := n.(*ast.UnaryExpr)
+	return ok && u.Op == token.ARROW && t.checks[u.Pos()]
+}
+
+func (t *translator) text(pos, end token.Pos) string {
+	return string(t.src[t.file.Offset(pos):t.file.Offset(end)])
+}
+
+// directive makes the position of the following text pos.
+func (t *translator) directive(pos token.Pos) string {
+	p := t.fset.Position(pos)
+	return fmt.Sprintf("/*line %s:%d:%d*/", t.name, p.Line, p.Column)
+}
+
+// render returns the source between pos and end with all replacements in
+// this range, except skip, applied.
+func (t *translator) render(pos, end token.Pos, skip *replacement) string {
+	var b strings.Builder
+	cur := pos
+	for _, r := range t.replacements {
+		if r == skip || r.pos < cur || r.end > end {
+			continue
+		}
+		b.WriteString(t.text(cur, r.pos))
+		b.WriteString(r.render())
+		cur = r.end
+	}
+	b.WriteString(t.text(cur, end))
+	return b.String()
+}
+
+func (t *translator) function(typ *ast.FuncType, body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			t.function(n.Type, n.Body)
+			return false
+		case *ast.BlockStmt:
+			t.statements(typ, n.List)
+		case *ast.CaseClause:
+			t.statements(typ, n.Body)
+		case *ast.CommClause:
+			t.statements(typ, n.Body)
+		}
+		return true
+	})
+}
+
+func (t *translator) statements(typ *ast.FuncType, list []ast.Stmt) {
+	for _, stmt := range list {
+		for {
+			labeled, ok := stmt.(*ast.LabeledStmt)
+			if !ok {
+				break
+			}
+			stmt = labeled.Stmt
+		}
+		t.statement(typ, stmt)
+	}
+}
+
+// header returns the parts of stmt evaluated once before the statement's
+// own blocks, which is where checks can be hoisted from.
+func header(stmt ast.Stmt) []ast.Node {
+	switch stmt := stmt.(type) {
+	case *ast.IfStmt:
+		return []ast.Node{stmt.Init, stmt.Cond}
+	case *ast.SwitchStmt:
+		return []ast.Node{stmt.Init, stmt.Tag}
+	case *ast.TypeSwitchStmt:
+		return []ast.Node{stmt.Init, stmt.Assign}
+	case *ast.ForStmt:
+		return []ast.Node{stmt.Init}
+	case *ast.RangeStmt:
+		return []ast.Node{stmt.X}
+	case *ast.BlockStmt, *ast.SelectStmt:
+		return nil
+	default:
+		return []ast.Node{stmt}
+	}
+}
+
+// collect returns the checks in node, inner ones first.
+func (t *translator) collect(node ast.Node) []*ast.UnaryExpr {
+	var (
+		checks []*ast.UnaryExpr
+		stack  []ast.Node
+	)
+	var visit func(ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch e := n.(type) {
+		case nil:
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if t.isCheck(top) {
+				checks = append(checks, top.(*ast.UnaryExpr))
+			}
+			return false
+		case *ast.FuncLit:
+			return false
+		case *ast.BinaryExpr:
+			if e.Op == token.LAND || e.Op == token.LOR {
+				// The right operand is not always evaluated.
+				ast.Inspect(e.X, visit)
+				return false
+			}
+		}
+		stack = append(stack, n)
+		return true
+	}
+	if node != nil {
+		ast.Inspect(node, visit)
+	}
+	return checks
+}
+
+func (t *translator) statement(typ *ast.FuncType, stmt ast.Stmt) {
+	var checks []*ast.UnaryExpr
+	for _, node := range header(stmt) {
+		checks = append(checks, t.collect(node)...)
+	}
+	if len(checks) == 0 {
+		return
+	}
+	for _, c := range checks {
+		t.consumed[c.Pos()] = true
+	}
+
+	results, ok := t.zeros(typ)
+	if !ok {
+		t.errorf(checks[0].Pos(), "check used in a function whose last result is not error")
+		return
+	}
+
+	// The outermost check may be the whole statement.
+	var top *ast.UnaryExpr
+	last := checks[len(checks)-1]
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		if s.X == last {
+			top = last
+		}
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 && s.Rhs[0] == last && (s.Tok == token.DEFINE || s.Tok == token.ASSIGN) {
+			top = last
+		}
+	}
+	if top != nil {
+		checks = checks[:len(checks)-1]
+	}
+
+	values := make(map[*ast.UnaryExpr]string)
+	for _, c := range checks {
+		t.counter++
+		name := fmt.Sprintf("checkValue%d", t.counter)
+		values[c] = name
+		c := c
+		t.replacements = append(t.replacements, &replacement{
+			pos: c.Pos(),
+			end: c.End(),
+			render: func() string {
+				return name + t.directive(c.End())
+			},
+		})
+	}
+
+	self := &replacement{pos: stmt.Pos(), end: stmt.End()}
+	self.render = func() string {
+		var b strings.Builder
+		for _, c := range checks {
+			t.counter++
+			errName := fmt.Sprintf("checkErr%d", t.counter)
+			fmt.Fprintf(&b, "%s, %s := %s%s; ", values[c], errName, t.directive(c.X.Pos()), t.render(c.X.Pos(), c.X.End(), nil))
+			b.WriteString(ifErr(errName, results))
+			b.WriteString("; ")
+		}
+
+		if top == nil {
+			b.WriteString(t.directive(stmt.Pos()))
+			b.WriteString(t.render(stmt.Pos(), stmt.End(), self))
+		} else {
+			t.counter++
+			errName := fmt.Sprintf("checkErr%d", t.counter)
+			x := t.directive(top.X.Pos()) + t.render(top.X.Pos(), top.X.End(), nil)
+			switch s := stmt.(type) {
+			case *ast.ExprStmt:
+				fmt.Fprintf(&b, "if %[1]s := %[2]s; %[1]s != nil { return %[3]s }", errName, x, returnList(results, errName))
+			case *ast.AssignStmt:
+				lhs := t.directive(s.Lhs[0].Pos()) + t.render(s.Lhs[0].Pos(), s.Lhs[len(s.Lhs)-1].End(), nil)
+				if s.Tok == token.DEFINE {
+					fmt.Fprintf(&b, "%s, %s := %s; %s", lhs, errName, x, ifErr(errName, results))
+				} else {
+					names := make([]string, len(s.Lhs))
+					for n := range names {
+						names[n] = fmt.Sprintf("checkValue%d_%d", t.counter, n)
+					}
+					values := strings.Join(names, ", ")
+					fmt.Fprintf(&b, "%s, %s := %s; %s; %s = %s", values, errName, x, ifErr(errName, results), lhs, values)
+				}
+			}
+		}
+
+		b.WriteString(t.directive(stmt.End()))
+		return b.String()
+	}
+	t.replacements = append(t.replacements, self)
+}
+
+func ifErr(name string, results []string) string {
+	return fmt.Sprintf("if %s != nil { return %s }", name, returnList(results, name))
+}
+
+func returnList(results []string, err string) string {
+	return strings.Join(append(results[:len(results):len(results)], err), ", ")
+}
+
+// zeros returns the zero values of all results of typ but the last one,
+// which has to be error.
+func (t *translator) zeros(typ *ast.FuncType) ([]string, bool) {
+	if typ.Results == nil {
+		return nil, false
+	}
+
+	var types []ast.Expr
+	for _, field := range typ.Results.List {
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+		for n := 0; n < count; n++ {
+			types = append(types, field.Type)
+		}
+	}
+
+	last, ok := types[len(types)-1].(*ast.Ident)
+	if !ok || last.Name != "error" {
+		return nil, false
+	}
+
+	zeros := make([]string, len(types)-1)
+	for n, typ := range types[:len(types)-1] {
+		zeros[n] = t.zero(typ)
+	}
+	return zeros, true
+}
+
+func (t *translator) zero(typ ast.Expr) string {
+	switch typ := typ.(type) {
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return "nil"
+	case *ast.ArrayType:
+		if typ.Len == nil {
+			return "nil"
+		}
+	case *ast.ParenExpr:
+		return t.zero(typ.X)
+	case *ast.Ident:
+		switch typ.Name {
+		case "bool":
+			return "false"
+		case "string":
+			return `""`
+		case "error", "any":
+			return "nil"
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+			"float32", "float64", "complex64", "complex128", "byte", "rune":
+			return "0"
+		}
+	}
+	return "*new(" + t.text(typ.Pos(), typ.End()) + ")"
+}
@@ -0,0 +1,14 @@
+// Command stickyerr runs the stickyerr analyzer, standalone or with
+//
+//	go vet -vettool=$(which stickyerr) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/jkmar/go_less_verbose_error_handling/analysis/stickyerr"
+)
+
+func main() {
+	singlechecker.Main(stickyerr.Analyzer)
+}
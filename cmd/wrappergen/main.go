@@ -0,0 +1,250 @@
+// Command wrappergen generates ErrorReader-style sticky error wrappers for a
+// type.
+//
+// The generated struct holds the wrapped value and the first error returned
+// by any of its methods. Every exported method of the type gets a
+// counterpart without the trailing error result which does nothing and
+// returns zero values once an error was recorded:
+//
+//	//go:generate go run github.com/jkmar/go_less_verbose_error_handling/cmd/wrappergen -type *bufio.Reader
+//
+// generates ErrorReader with NewErrorReader, Err, ReadLine, ReadString and
+// so on. Step and Wrap of the generated type apply to the error of the next
+// call only.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/types"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jkmar/go_less_verbose_error_handling/internal/gen"
+)
+
+const generator = "wrappergen"
+
+type config struct {
+	name     string
+	field    string
+	receiver string
+	pkg      string
+	methods  map[string]bool
+	rename   map[string]string
+}
+
+func main() {
+	var (
+		typeRef  = flag.String("type", "", "wrapped type, for example *bufio.Reader, *os.File or io.Writer")
+		name     = flag.String("name", "", "name of the generated type; defaults to Error<Type>")
+		field    = flag.String("field", "", "name of the field holding the wrapped value; defaults to <type>")
+		receiver = flag.String("receiver", "", "name of the receiver variable; defaults to the first letter of <type>")
+		output   = flag.String("output", "", "output file; defaults to <name>_gen.go")
+		pkg      = flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file; defaults to $GOPACKAGE")
+		methods  = flag.String("methods", "", "comma separated methods to wrap; defaults to all exported methods, methods go vet expects to have standard signatures are only wrapped when renamed")
+		rename   = flag.String("rename", "", "comma separated Method=NewName pairs renaming the generated methods")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -type [*]importpath.Name [flags]\n", generator)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeRef == "" || flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	typeName := (*typeRef)[strings.LastIndex(*typeRef, ".")+1:]
+	if *name == "" {
+		*name = "Error" + typeName
+	}
+	if *field == "" {
+		*field = uncapitalize(typeName)
+	}
+	if *receiver == "" {
+		*receiver = strings.ToLower(typeName[:1])
+	}
+	if *pkg == "" {
+		*pkg = "main"
+	}
+	if *output == "" {
+		*output = strings.ToLower(*name) + "_gen.go"
+	}
+
+	src, err := generate(&config{
+		name:     *name,
+		field:    *field,
+		receiver: *receiver,
+		pkg:      *pkg,
+		methods:  set(*methods),
+		rename:   pairs(*rename),
+	}, *typeRef)
+	if err != nil {
+		fatal(err)
+	}
+
+	if err = os.WriteFile(*output, src, 0o644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", generator, err)
+	os.Exit(1)
+}
+
+func set(list string) map[string]bool {
+	result := make(map[string]bool)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result[item] = true
+		}
+	}
+	return result
+}
+
+func pairs(list string) map[string]string {
+	result := make(map[string]string)
+	for item := range set(list) {
+		from, to, _ := strings.Cut(item, "=")
+		result[strings.TrimSpace(from)] = strings.TrimSpace(to)
+	}
+	return result
+}
+
+func uncapitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToLower(r)) + s[size:]
+}
+
+func generate(cfg *config, typeRef string) ([]byte, error) {
+	_, typ, err := gen.LookupType(typeRef)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := gen.NewImports("")
+	imports.TypeString(typ)
+	imports.Name(types.NewPackage("fmt", "fmt"))
+
+	methods := wrappable(typ, cfg.methods, cfg.rename)
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("type %s has no exported methods", typeRef)
+	}
+
+	// Register every import before choosing parameter names so that no
+	// parameter shadows a package used by the generated code.
+	for _, m := range methods {
+		sig := m.Type().(*types.Signature)
+		imports.Params(sig, make([]string, sig.Params().Len()))
+		imports.ResultList(imports.Results(sig))
+	}
+
+	var body bytes.Buffer
+	writeDeclaration(&body, cfg, imports, typ)
+	for _, m := range methods {
+		writeMethod(&body, cfg, imports, m)
+	}
+
+	return gen.Source(generator, cfg.pkg, imports, body.Bytes())
+}
+
+// canonical lists the methods go vet expects to have well-known signatures.
+// Dropping their error result would make the wrapper look like a broken
+// io.Reader, io.Seeker and so on, so they are only wrapped on request and
+// should be renamed with -rename.
+var canonical = map[string]bool{
+	"Format":        true,
+	"GobDecode":     true,
+	"GobEncode":     true,
+	"MarshalJSON":   true,
+	"MarshalXML":    true,
+	"Peek":          true,
+	"ReadByte":      true,
+	"ReadFrom":      true,
+	"ReadRune":      true,
+	"Scan":          true,
+	"Seek":          true,
+	"UnmarshalJSON": true,
+	"UnmarshalXML":  true,
+	"UnreadByte":    true,
+	"UnreadRune":    true,
+	"WriteByte":     true,
+	"WriteTo":       true,
+}
+
+func wrappable(typ types.Type, include map[string]bool, rename map[string]string) []*types.Func {
+	var methods []*types.Func
+	set := types.NewMethodSet(typ)
+	for n := 0; n < set.Len(); n++ {
+		m := set.At(n).Obj().(*types.Func)
+		// Err, Step and Wrap are taken by the methods of the wrapper.
+		if !m.Exported() || m.Name() == "Err" || m.Name() == "Step" || m.Name() == "Wrap" {
+			continue
+		}
+		_, renamed := rename[m.Name()]
+		if len(include) > 0 && !include[m.Name()] || canonical[m.Name()] && !renamed {
+			continue
+		}
+		methods = append(methods, m)
+	}
+	return methods
+}
+
+func writeDeclaration(buf *bytes.Buffer, cfg *config, imports *gen.Imports, typ types.Type) {
+	wrapped := imports.TypeString(typ)
+	fmtName := imports.Name(types.NewPackage("fmt", "fmt"))
+	fmt.Fprintf(buf, "type %s struct {\n\terr error\n\twrap func(error) error\n\t%s %s\n}\n\n", cfg.name, cfg.field, wrapped)
+	fmt.Fprintf(buf, "func New%s(%s %s) *%[1]s {\n\treturn &%[1]s{\n\t\t%[2]s: %[2]s,\n\t}\n}\n\n", cfg.name, cfg.field, wrapped)
+	fmt.Fprintf(buf, "func (%s *%s) Err() error {\n\treturn %[1]s.err\n}\n\n", cfg.receiver, cfg.name)
+	fmt.Fprintf(buf, "func (%s *%s) Step(name string) *%[2]s {\n\treturn %[1]s.Wrap(func(err error) error {\n\t\treturn %[3]s.Errorf(\"%%s: %%w\", name, err)\n\t})\n}\n\n", cfg.receiver, cfg.name, fmtName)
+	fmt.Fprintf(buf, "func (%s *%s) Wrap(wrap func(error) error) *%[2]s {\n\tif %[1]s.err == nil {\n\t\t%[1]s.wrap = wrap\n\t}\n\treturn %[1]s\n}\n\n", cfg.receiver, cfg.name)
+	fmt.Fprintf(buf, "func (%s *%s) set(err error) {\n\tif err != nil && %[1]s.wrap != nil {\n\t\terr = %[1]s.wrap(err)\n\t}\n\t%[1]s.err, %[1]s.wrap = err, nil\n}\n\n", cfg.receiver, cfg.name)
+}
+
+func writeMethod(buf *bytes.Buffer, cfg *config, imports *gen.Imports, m *types.Func) {
+	sig := m.Type().(*types.Signature)
+	results := imports.Results(sig)
+	names := gen.ParamNames(sig, func(name string) bool {
+		return name == cfg.receiver || name == "err" || strings.HasPrefix(name, "result") || imports.Used(name)
+	})
+	resultNames := make([]string, len(results))
+	for n := range results {
+		resultNames[n] = "result"
+		if len(results) > 1 {
+			resultNames[n] += fmt.Sprint(n)
+		}
+	}
+
+	name := m.Name()
+	if renamed, ok := cfg.rename[name]; ok {
+		name = renamed
+	}
+
+	fmt.Fprintf(buf, "func (%s *%s) %s(%s) %s {\n", cfg.receiver, cfg.name, name, imports.Params(sig, names), imports.ResultList(results))
+	fmt.Fprintf(buf, "\tif %s.err != nil {\n\t\treturn %s\n\t}\n\n", cfg.receiver, imports.Zeros(results))
+
+	call := fmt.Sprintf("%s.%s.%s(%s)", cfg.receiver, cfg.field, m.Name(), gen.Args(sig, names))
+
+	switch {
+	case !gen.ReturnsError(sig) && len(results) == 0:
+		fmt.Fprintf(buf, "\t%s\n}\n\n", call)
+		return
+	case !gen.ReturnsError(sig):
+		fmt.Fprintf(buf, "\treturn %s\n}\n\n", call)
+		return
+	case len(results) == 0:
+		fmt.Fprintf(buf, "\t%s.set(%s)\n}\n\n", cfg.receiver, call)
+		return
+	}
+
+	// set wraps the error of the call following Step or Wrap.
+	fmt.Fprintf(buf, "\t%s, err := %s\n", strings.Join(resultNames, ", "), call)
+	fmt.Fprintf(buf, "\t%s.set(err)\n", cfg.receiver)
+	fmt.Fprintf(buf, "\treturn %s\n}\n\n", strings.Join(resultNames, ", "))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START decodeYAML OMIT
+// decodeYAML stands in for yaml.Unmarshal, it only decodes flat mappings.
+func decodeYAML(data []byte, v interface{}) error {
+	result, ok := v.(*map[string]string)
+	if !ok {
+		return fmt.Errorf("yaml: cannot decode into %T", v)
+	}
+	*result = make(map[string]string)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			return fmt.Errorf("yaml: %q is not a key: value pair", scanner.Text())
+		}
+		(*result)[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return scanner.Err()
+}
+
+// END decodeYAML OMIT
+
+// START main OMIT
+func main() {
+	fmt.Println(errorhandling.GetCommandsFromFile("resources/valid"))
+	fmt.Println(errorhandling.GetCommandsFromFile("resources/valid_commented"))
+	fmt.Println(errorhandling.GetCommandsFromFile("resources/valid_kv"))
+	fmt.Println(errorhandling.GetCommandsFromFile("resources/valid.ini"))
+	fmt.Println(errorhandling.GetCommandsFromFile("resources/invalid_ini"))
+	fmt.Println(errorhandling.GetCommandsFromFileContext(context.Background(), "resources/valid.ini"))
+
+	fmt.Println(errorhandling.GetCommandsFromFile("resources/valid.yaml"))
+	errorhandling.RegisterEncoding(errorhandling.YAML, decodeYAML) // HL_encoding
+	fmt.Println(errorhandling.GetCommandsFromFile("resources/valid.yaml"))
+}
+
+// END main OMIT
@@ -0,0 +1,62 @@
+// Code generated by wrappergen; DO NOT EDIT.
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type ErrorFile struct {
+	err  error
+	wrap func(error) error
+	file *os.File
+}
+
+func NewErrorFile(file *os.File) *ErrorFile {
+	return &ErrorFile{
+		file: file,
+	}
+}
+
+func (f *ErrorFile) Err() error {
+	return f.err
+}
+
+func (f *ErrorFile) Step(name string) *ErrorFile {
+	return f.Wrap(func(err error) error {
+		return fmt.Errorf("%s: %w", name, err)
+	})
+}
+
+func (f *ErrorFile) Wrap(wrap func(error) error) *ErrorFile {
+	if f.err == nil {
+		f.wrap = wrap
+	}
+	return f
+}
+
+func (f *ErrorFile) set(err error) {
+	if err != nil && f.wrap != nil {
+		err = f.wrap(err)
+	}
+	f.err, f.wrap = err, nil
+}
+
+func (f *ErrorFile) SeekTo(offset int64, whence int) int64 {
+	if f.err != nil {
+		return 0
+	}
+
+	result, err := f.file.Seek(offset, whence)
+	f.set(err)
+	return result
+}
+
+func (f *ErrorFile) Truncate(size int64) {
+	if f.err != nil {
+		return
+	}
+
+	f.set(f.file.Truncate(size))
+}
@@ -0,0 +1,54 @@
+// Code generated by wrappergen; DO NOT EDIT.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+)
+
+type ErrorReader struct {
+	err    error
+	wrap   func(error) error
+	reader *bufio.Reader
+}
+
+func NewErrorReader(reader *bufio.Reader) *ErrorReader {
+	return &ErrorReader{
+		reader: reader,
+	}
+}
+
+func (r *ErrorReader) Err() error {
+	return r.err
+}
+
+func (r *ErrorReader) Step(name string) *ErrorReader {
+	return r.Wrap(func(err error) error {
+		return fmt.Errorf("%s: %w", name, err)
+	})
+}
+
+func (r *ErrorReader) Wrap(wrap func(error) error) *ErrorReader {
+	if r.err == nil {
+		r.wrap = wrap
+	}
+	return r
+}
+
+func (r *ErrorReader) set(err error) {
+	if err != nil && r.wrap != nil {
+		err = r.wrap(err)
+	}
+	r.err, r.wrap = err, nil
+}
+
+func (r *ErrorReader) ReadLine() ([]byte, bool) {
+	if r.err != nil {
+		return nil, false
+	}
+
+	result0, result1, err := r.reader.ReadLine()
+	r.set(err)
+	return result0, result1
+}
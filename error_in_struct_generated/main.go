@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START generate OMIT
+//go:generate go run github.com/jkmar/go_less_verbose_error_handling/cmd/wrappergen -type *bufio.Reader -methods ReadLine
+//go:generate go run github.com/jkmar/go_less_verbose_error_handling/cmd/wrappergen -type *os.File -methods Seek,Truncate -rename Seek=SeekTo
+// END generate OMIT
+
+// START getCommandsFromFile OMIT
+func getCommandsFromFile(filename string) ([]string, error) {
+	rawConfiguration, err := readConfiguration(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: read configuration: %w", filename, err)
+	}
+
+	configuration, err := errorhandling.ParseConfiguration(rawConfiguration)
+	if err != nil {
+		return nil, fmt.Errorf("%s: parse configuration: %w", filename, err)
+	}
+
+	commands, err := errorhandling.CalculateCommands(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("%s: calculate commands: %w", filename, err)
+	}
+
+	return commands, nil
+}
+
+// END getCommandsFromFile OMIT
+
+// START readConfiguration OMIT
+func readConfiguration(filename string) (*errorhandling.RawConfiguration, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buffered := bufio.NewReader(f)
+	reader := NewErrorReader(buffered)                                         // HL_error_in_struct
+	header, _ := reader.Wrap(errorhandling.TooFewLinesIn("header")).ReadLine() // HL_error_in_struct
+	body, _ := reader.Wrap(errorhandling.TooFewLinesIn("body")).ReadLine()     // HL_error_in_struct
+	if err = reader.Err(); err != nil {                                        // HL_error_in_struct
+		return nil, err // HL_error_in_struct
+	} // HL_error_in_struct
+
+	return errorhandling.NewRawConfiguration(filename, header, body, buffered)
+}
+
+// END readConfiguration OMIT
+
+// START preallocExtendTrunc OMIT
+func preallocExtendTrunc(f *os.File, sizeInBytes int64) error {
+	file := NewErrorFile(f)                      // HL_error_in_struct
+	curOff := file.SeekTo(0, io.SeekCurrent)     // HL_error_in_struct
+	size := file.SeekTo(sizeInBytes, io.SeekEnd) // HL_error_in_struct
+	file.SeekTo(curOff, io.SeekStart)            // HL_error_in_struct
+	if err := file.Err(); err != nil {           // HL_error_in_struct
+		return err // HL_error_in_struct
+	} // HL_error_in_struct
+	if sizeInBytes <= size { // HL_error_in_struct
+		file.Truncate(sizeInBytes) // HL_error_in_struct
+	} // HL_error_in_struct
+	return file.Err() // HL_error_in_struct
+}
+
+// END preallocExtendTrunc OMIT
+
+// START main OMIT
+func main() {
+	fmt.Println(getCommandsFromFile("resources/not_enough_lines"))
+	fmt.Println(getCommandsFromFile("resources/version_not_a_number"))
+	fmt.Println(getCommandsFromFile("resources/invalid_json"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_version"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_mode"))
+	fmt.Println(getCommandsFromFile("resources/valid"))
+
+	f, err := os.CreateTemp("", "prealloc")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	fmt.Println(preallocExtendTrunc(f, 1024))
+}
+
+// END main OMIT
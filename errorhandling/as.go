@@ -0,0 +1,107 @@
+package errorhandling
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeError is returned by As and its variants for a value of another type
+// than the requested one.
+type TypeError struct {
+	Want reflect.Type
+	Got  reflect.Type
+}
+
+func (e *TypeError) Error() string {
+	got := "nil"
+	if e.Got != nil {
+		got = e.Got.String()
+	}
+	return fmt.Sprintf("%s instead of %s", got, e.Want)
+}
+
+// START As OMIT
+func As[T any](x interface{}, err error) (T, error) {
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return as[T](x)
+}
+
+// END As OMIT
+
+// as returns x as a T, nil is the zero value of the types which can be nil.
+func as[T any](x interface{}) (T, error) {
+	if t, ok := x.(T); ok {
+		return t, nil
+	}
+	var zero T
+	want := reflect.TypeFor[T]()
+	if x == nil && nillable(want) {
+		return zero, nil
+	}
+	return zero, &TypeError{Want: want, Got: reflect.TypeOf(x)}
+}
+
+// AsSlice is As for slices, it also converts slices and arrays of other types
+// whose elements are all of type E, like an []interface{}.
+func AsSlice[E any](x interface{}, err error) ([]E, error) {
+	if err != nil {
+		return nil, err
+	}
+	if xs, ok := x.([]E); ok || x == nil {
+		return xs, nil
+	}
+
+	v := reflect.ValueOf(x)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, &TypeError{Want: reflect.TypeFor[[]E](), Got: v.Type()}
+	}
+	result := make([]E, v.Len())
+	for n := range result {
+		if result[n], err = as[E](v.Index(n).Interface()); err != nil {
+			return nil, fmt.Errorf("element %d: %w", n, err)
+		}
+	}
+	return result, nil
+}
+
+// AsMap is As for maps, it also converts maps of other types whose keys are
+// all of type K and values of type V, like a map[string]interface{}.
+func AsMap[K comparable, V any](x interface{}, err error) (map[K]V, error) {
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := x.(map[K]V); ok || x == nil {
+		return m, nil
+	}
+
+	v := reflect.ValueOf(x)
+	if v.Kind() != reflect.Map {
+		return nil, &TypeError{Want: reflect.TypeFor[map[K]V](), Got: v.Type()}
+	}
+	result := make(map[K]V, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		key, err := as[K](iter.Key().Interface())
+		if err != nil {
+			return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+		}
+		if result[key], err = as[V](iter.Value().Interface()); err != nil {
+			return nil, fmt.Errorf("value of %v: %w", iter.Key(), err)
+		}
+	}
+	return result, nil
+}
+
+// AsPointer is As for pointers, it also takes a T and returns a pointer to a
+// copy of it.
+func AsPointer[T any](x interface{}, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := x.(T); ok {
+		return &t, nil
+	}
+	return as[*T](x)
+}
@@ -0,0 +1,110 @@
+package errorhandling
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func checkAs(t *testing.T, got, want interface{}, err, wantErr error) {
+	t.Helper()
+	switch {
+	case wantErr == nil && err != nil:
+		t.Errorf("unexpected error: %v", err)
+	case wantErr == errTypeError:
+		var typeError *TypeError
+		if !errors.As(err, &typeError) {
+			t.Errorf("got error %v, want a *TypeError", err)
+		}
+	case wantErr != nil && !errors.Is(err, wantErr):
+		t.Errorf("got error %v, want %v", err, wantErr)
+	case wantErr == nil && !reflect.DeepEqual(got, want):
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+// errTypeError stands for any *TypeError in the tests.
+var errTypeError = errors.New("type error")
+
+func TestAsSlice(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name string
+		x    interface{}
+		err  error
+		want []int
+		fail error
+	}{
+		{name: "slice", x: []int{1, 2}, want: []int{1, 2}},
+		{name: "interface elements", x: []interface{}{1, 2}, want: []int{1, 2}},
+		{name: "array", x: [2]int{1, 2}, want: []int{1, 2}},
+		{name: "empty", x: []interface{}{}, want: []int{}},
+		{name: "nil", x: nil, want: nil},
+		{name: "error", x: []int{1}, err: errFailed, fail: errFailed},
+		{name: "wrong element", x: []interface{}{1, "2"}, fail: errTypeError},
+		{name: "not a slice", x: 1, fail: errTypeError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := AsSlice[int](test.x, test.err)
+			checkAs(t, got, test.want, err, test.fail)
+		})
+	}
+}
+
+func TestAsMap(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name string
+		x    interface{}
+		err  error
+		want map[string]int
+		fail error
+	}{
+		{name: "map", x: map[string]int{"a": 1}, want: map[string]int{"a": 1}},
+		{name: "interface values", x: map[string]interface{}{"a": 1}, want: map[string]int{"a": 1}},
+		{name: "interface keys", x: map[interface{}]int{"a": 1}, want: map[string]int{"a": 1}},
+		{name: "nil", x: nil, want: nil},
+		{name: "error", x: map[string]int{}, err: errFailed, fail: errFailed},
+		{name: "wrong value", x: map[string]interface{}{"a": "1"}, fail: errTypeError},
+		{name: "wrong key", x: map[interface{}]int{1: 1}, fail: errTypeError},
+		{name: "not a map", x: []int{1}, fail: errTypeError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := AsMap[string, int](test.x, test.err)
+			checkAs(t, got, test.want, err, test.fail)
+		})
+	}
+}
+
+func TestAsPointer(t *testing.T) {
+	errFailed := errors.New("failed")
+	configuration := &Configuration{Version: 2}
+
+	tests := []struct {
+		name string
+		x    interface{}
+		err  error
+		want *Configuration
+		fail error
+	}{
+		{name: "pointer", x: configuration, want: configuration},
+		{name: "value", x: Configuration{Version: 2}, want: &Configuration{Version: 2}},
+		{name: "nil", x: nil, want: nil},
+		{name: "nil pointer", x: (*Configuration)(nil), want: nil},
+		{name: "error", x: configuration, err: errFailed, fail: errFailed},
+		{name: "wrong type", x: &RawConfiguration{}, fail: errTypeError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := AsPointer[Configuration](test.x, test.err)
+			checkAs(t, got, test.want, err, test.fail)
+			if test.name == "pointer" && got != configuration {
+				t.Error("the pointer was copied")
+			}
+		})
+	}
+}
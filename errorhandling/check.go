@@ -0,0 +1,140 @@
+package errorhandling
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// START ErrorParser  OMIT
+type ErrorParser struct {
+	err error
+}
+
+// END ErrorParser  OMIT
+
+// START NewErrorParser OMIT
+func NewErrorParser() *ErrorParser {
+	return &ErrorParser{}
+}
+
+// END NewErrorParser OMIT
+
+// START ErrorParser Err OMIT
+func (p *ErrorParser) Err() error {
+	return p.err
+}
+
+// END ErrorParser Err OMIT
+
+// START ErrorParser ParseVersion OMIT
+func (p *ErrorParser) ParseVersion(configuration *RawConfiguration) int {
+	if p.err != nil {
+		return 0
+	}
+
+	result, err := strconv.Atoi(string(configuration.Header))
+	p.err = NewVersionError(err)
+	return result
+}
+
+// END ErrorParser ParseVersion OMIT
+
+// START ErrorParser ParseData OMIT
+func (p *ErrorParser) ParseData(configuration *RawConfiguration) map[string]string {
+	if p.err != nil {
+		return nil
+	}
+
+	var result map[string]string
+	p.err = NewBodySyntaxError(Decode(configuration.Encoding, configuration.Body, &result))
+	return result
+}
+
+// END ErrorParser ParseData OMIT
+
+// START ErrorChecker  OMIT
+type ErrorChecker struct {
+	err  error
+	wrap func(error) error
+}
+
+// END ErrorChecker  OMIT
+
+// START NewErrorChecker OMIT
+func NewErrorChecker() *ErrorChecker {
+	return &ErrorChecker{}
+}
+
+// END NewErrorChecker OMIT
+
+// START ErrorChecker Err OMIT
+func (c *ErrorChecker) Err() error {
+	return c.err
+}
+
+// END ErrorChecker Err OMIT
+
+// START ErrorChecker Step OMIT
+func (c *ErrorChecker) Step(name string) *ErrorChecker {
+	return c.Wrap(func(err error) error {
+		return fmt.Errorf("%s: %w", name, err)
+	})
+}
+
+// END ErrorChecker Step OMIT
+
+// START ErrorChecker Wrap OMIT
+func (c *ErrorChecker) Wrap(wrap func(error) error) *ErrorChecker {
+	if c.err == nil {
+		c.wrap = wrap
+	}
+	return c
+}
+
+// END ErrorChecker Wrap OMIT
+
+// START ErrorChecker set OMIT
+func (c *ErrorChecker) set(err error) {
+	if err != nil && c.wrap != nil {
+		err = c.wrap(err)
+	}
+	c.err, c.wrap = err, nil
+}
+
+// END ErrorChecker set OMIT
+
+// START ErrorChecker StrconvAtoi OMIT
+func (c *ErrorChecker) StrconvAtoi(s string) int {
+	if c.err != nil {
+		return 0
+	}
+
+	result, err := strconv.Atoi(s)
+	c.set(err)
+	return result
+}
+
+// END ErrorChecker StrconvAtoi OMIT
+
+// START ErrorChecker JsonUnmarshal OMIT
+func (c *ErrorChecker) JsonUnmarshal(data []byte, v interface{}) {
+	if c.err != nil {
+		return
+	}
+
+	c.set(json.Unmarshal(data, v))
+}
+
+// END ErrorChecker JsonUnmarshal OMIT
+
+// START ErrorChecker Decode OMIT
+func (c *ErrorChecker) Decode(encoding string, data []byte, v interface{}) {
+	if c.err != nil {
+		return
+	}
+
+	c.set(Decode(encoding, data, v))
+}
+
+// END ErrorChecker Decode OMIT
@@ -0,0 +1,113 @@
+package errorhandling
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Stage names a function passed to Compose, its errors are wrapped with the
+// name like by StepEither.
+type Stage struct {
+	Name string
+	F    interface{}
+}
+
+// ChainError is returned by Compose when the result of a stage cannot be
+// passed to the next one. Stage is the index of the stage taking the value,
+// the number of stages for the result of the chain.
+type ChainError struct {
+	Stage int
+	Name  string
+	Want  reflect.Type
+	Got   reflect.Type
+}
+
+func (e *ChainError) Error() string {
+	stage := fmt.Sprintf("stage %d", e.Stage)
+	if e.Name != "" {
+		stage += fmt.Sprintf(" (%s)", e.Name)
+	}
+	return fmt.Sprintf("%s: takes %s, but is given %s", stage, e.Want, e.Got)
+}
+
+type composedStage struct {
+	name string
+	f    *reflectFunc
+}
+
+// START Compose OMIT
+func Compose[In, Out any](fs ...interface{}) (func(In) (Out, error), error) {
+	stages := make([]composedStage, len(fs))
+	typ := reflect.TypeFor[In]()
+	for n, f := range fs {
+		stage, err := newComposedStage(f)
+		if err != nil {
+			return nil, fmt.Errorf("stage %d: %w", n, err)
+		}
+		want := stage.f.input()
+		if want != nil && !typ.AssignableTo(want) {
+			return nil, &ChainError{Stage: n, Name: stage.name, Want: want, Got: typ}
+		}
+		typ = stage.f.output(typ)
+		stages[n] = stage
+	}
+	if want := reflect.TypeFor[Out](); !typ.AssignableTo(want) {
+		return nil, &ChainError{Stage: len(fs), Name: "result", Want: want, Got: typ}
+	}
+
+	return func(in In) (Out, error) {
+		var x interface{} = in
+		for _, stage := range stages {
+			var err error
+			if x, err = stage.call(x); err != nil {
+				var zero Out
+				return zero, err
+			}
+		}
+		out, _ := x.(Out)
+		return out, nil
+	}, nil
+}
+
+// END Compose OMIT
+
+func newComposedStage(f interface{}) (composedStage, error) {
+	var stage composedStage
+	if named, ok := f.(Stage); ok {
+		stage.name, f = named.Name, named.F
+	}
+	var err error
+	stage.f, err = newReflectFunc(f, false)
+	return stage, err
+}
+
+func (s composedStage) call(x interface{}) (interface{}, error) {
+	y, err := s.f.call(context.Background(), x)
+	if err != nil && s.name != "" {
+		err = fmt.Errorf("%s: %w", s.name, err)
+	}
+	return y, err
+}
+
+// input returns the type of the value the function takes, nil if it takes
+// none. Functions with several parameters take an []interface{}, the types
+// of its elements are only checked when it is called.
+func (w *reflectFunc) input() reflect.Type {
+	switch {
+	case len(w.in) == 0:
+		return nil
+	case len(w.in) == 1 && !w.variadic:
+		return w.in[0]
+	}
+	return reflect.TypeFor[[]interface{}]()
+}
+
+// output returns the type of the value the function returns when given a
+// value of type in.
+func (w *reflectFunc) output(in reflect.Type) reflect.Type {
+	if w.value {
+		return w.f.Type().Out(0)
+	}
+	return in
+}
@@ -0,0 +1,151 @@
+package errorhandling
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	errFailed := errors.New("failed")
+	double := func(n int) int { return n * 2 }
+	fail := func(int) error { return errFailed }
+
+	t.Run("chain", func(t *testing.T) {
+		var calls []string
+		record := func(name string) func(int) error {
+			return func(int) error {
+				calls = append(calls, name)
+				return nil
+			}
+		}
+
+		f, err := Compose[string, string](strconv.Atoi, record("first"), double, record("second"), strconv.Itoa)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := f("21")
+		if err != nil || got != "42" {
+			t.Errorf("got %q, %v, want 42", got, err)
+		}
+		if want := []string{"first", "second"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("stages ran in order %v, want %v", calls, want)
+		}
+	})
+
+	t.Run("no stages", func(t *testing.T) {
+		f, err := Compose[int, int]()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := f(7); err != nil || got != 7 {
+			t.Errorf("got %d, %v, want 7", got, err)
+		}
+	})
+
+	t.Run("several parameters", func(t *testing.T) {
+		split := func(s string) []interface{} {
+			name, count, _ := strings.Cut(s, "*")
+			n, _ := strconv.Atoi(count)
+			return []interface{}{name, n}
+		}
+		f, err := Compose[string, string](split, strings.Repeat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := f("ab*2"); err != nil || got != "abab" {
+			t.Errorf("got %q, %v, want abab", got, err)
+		}
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			fs      []interface{}
+			message string
+		}{
+			{name: "unnamed", fs: []interface{}{fail, double}, message: "failed"},
+			{name: "named", fs: []interface{}{Stage{Name: "check", F: fail}, double}, message: "check: failed"},
+			{name: "later", fs: []interface{}{double, Stage{Name: "check", F: fail}}, message: "check: failed"},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var after bool
+				fs := append(test.fs, func(n int) int {
+					after = true
+					return n
+				})
+				f, err := Compose[int, int](fs...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := f(1)
+				if !errors.Is(err, errFailed) || err.Error() != test.message || got != 0 {
+					t.Errorf("got %d, %v, want 0, %s", got, err, test.message)
+				}
+				if after {
+					t.Error("a stage after the failure ran")
+				}
+			})
+		}
+	})
+
+	t.Run("chain errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			compose func() error
+			stage   int
+			stageOf string
+			want    reflect.Type
+			got     reflect.Type
+		}{
+			{
+				name:    "input",
+				compose: func() error { _, err := Compose[string, string](double); return err },
+				stage:   0,
+				want:    reflect.TypeFor[int](),
+				got:     reflect.TypeFor[string](),
+			},
+			{
+				name: "named stage",
+				compose: func() error {
+					_, err := Compose[string, int](strconv.Atoi, Stage{Name: "format", F: strings.ToUpper})
+					return err
+				},
+				stage:   1,
+				stageOf: "format",
+				want:    reflect.TypeFor[string](),
+				got:     reflect.TypeFor[int](),
+			},
+			{
+				name:    "result",
+				compose: func() error { _, err := Compose[string, string](strconv.Atoi); return err },
+				stage:   1,
+				stageOf: "result",
+				want:    reflect.TypeFor[string](),
+				got:     reflect.TypeFor[int](),
+			},
+		}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var chainError *ChainError
+				if err := test.compose(); !errors.As(err, &chainError) {
+					t.Fatalf("got %v, want a *ChainError", err)
+				}
+				want := &ChainError{Stage: test.stage, Name: test.stageOf, Want: test.want, Got: test.got}
+				if *chainError != *want {
+					t.Errorf("got %v, want %v", chainError, want)
+				}
+			})
+		}
+	})
+
+	t.Run("signature", func(t *testing.T) {
+		_, err := Compose[int, int](double, 42)
+		if !errors.Is(err, ErrSignature) || !strings.HasPrefix(err.Error(), "stage 1: ") {
+			t.Errorf("got %v, want an ErrSignature of stage 1", err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package errorhandling
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// START NoContext OMIT
+func NoContext(f func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return f()
+	}
+}
+
+// END NoContext OMIT
+
+// START DoConcurrent OMIT
+func DoConcurrent(ctx context.Context, fs ...func(context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(fs))
+	var wg sync.WaitGroup
+	for n, f := range fs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if errs[n] = f(ctx); errs[n] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return firstError(errs)
+}
+
+// END DoConcurrent OMIT
+
+// firstError returns the first error in the order of the steps, preferring
+// errors which are not the cancellation caused by another failed step.
+func firstError(errs []error) error {
+	var canceled error
+	for _, err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, context.Canceled):
+			if canceled == nil {
+				canceled = err
+			}
+		default:
+			return err
+		}
+	}
+	return canceled
+}
+
+// START DoConcurrentAll OMIT
+func DoConcurrentAll(ctx context.Context, fs ...func(context.Context) error) error {
+	errs := make([]error, len(fs))
+	var wg sync.WaitGroup
+	for n, f := range fs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[n] = f(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// END DoConcurrentAll OMIT
@@ -0,0 +1,72 @@
+package errorhandling
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDoConcurrent(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	// waiting fails only once another step has canceled the context.
+	waiting := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ok := NoContext(func() error { return nil })
+		if err := DoConcurrent(context.Background(), ok, ok); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("first error", func(t *testing.T) {
+		err := DoConcurrent(context.Background(),
+			waiting,
+			NoContext(func() error { return errFirst }),
+			func(ctx context.Context) error {
+				<-ctx.Done()
+				return errSecond
+			},
+		)
+		if err != errFirst {
+			t.Errorf("got %v, want %v rather than the cancellation", err, errFirst)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := DoConcurrent(ctx, waiting, waiting); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+	})
+}
+
+func TestDoConcurrentAll(t *testing.T) {
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+
+	ran := make([]bool, 3)
+	step := func(n int, err error) func(context.Context) error {
+		return func(ctx context.Context) error {
+			ran[n] = ctx.Err() == nil
+			return err
+		}
+	}
+
+	err := DoConcurrentAll(context.Background(), step(0, errFirst), step(1, nil), step(2, errSecond))
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Errorf("got %v, want both errors joined", err)
+	}
+	if want := "first\nsecond"; err == nil || err.Error() != want {
+		t.Errorf("got %q, want the errors in the order of the steps", err)
+	}
+	for n, ran := range ran {
+		if !ran {
+			t.Errorf("step %d did not run with a live context", n)
+		}
+	}
+}
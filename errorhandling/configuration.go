@@ -0,0 +1,221 @@
+package errorhandling
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	Latest = 2
+
+	Down = "down"
+	Up   = "up"
+
+	DHCP   = "dhcp"
+	Static = "static"
+)
+
+type RawConfiguration struct {
+	Header []byte
+	Body   []byte
+	// Encoding of the body, JSON if empty.
+	Encoding string
+}
+
+type Configuration struct {
+	Version int
+	Data    map[string]string
+}
+
+// START getCommandsFromFile OMIT
+func GetCommandsFromFile(filename string) ([]string, error) {
+	// START GenericMonadGetCommandsFromFile OMIT
+	rawConfiguration, err := ReadConfiguration(filename) // HL_generic_monad
+	if err != nil {                                      // HL_generic_monad
+		return nil, fmt.Errorf("%s: read configuration: %w", filename, err) // HL_generic_monad
+	} // HL_generic_monad
+
+	configuration, err := ParseConfiguration(rawConfiguration) // HL_generic_monad
+	if err != nil {                                            // HL_generic_monad
+		return nil, fmt.Errorf("%s: parse configuration: %w", filename, err) // HL_generic_monad
+	} // HL_generic_monad
+
+	commands, err := CalculateCommands(configuration) // HL_generic_monad
+	if err != nil {                                   // HL_generic_monad
+		return nil, fmt.Errorf("%s: calculate commands: %w", filename, err) // HL_generic_monad
+	} // HL_generic_monad
+	// END GenericMonadGetCommandsFromFile OMIT
+
+	return commands, nil
+}
+
+// END getCommandsFromFile OMIT
+
+// START GetCommandsFromFileContext OMIT
+func GetCommandsFromFileContext(ctx context.Context, filename string) ([]string, error) {
+	rawConfiguration, err := ReadConfigurationContext(ctx, filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: read configuration: %w", filename, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	configuration, err := ParseConfiguration(rawConfiguration)
+	if err != nil {
+		return nil, fmt.Errorf("%s: parse configuration: %w", filename, err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	commands, err := CalculateCommands(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("%s: calculate commands: %w", filename, err)
+	}
+
+	return commands, nil
+}
+
+// END GetCommandsFromFileContext OMIT
+
+// START readConfiguration OMIT
+func ReadConfiguration(filename string) (*RawConfiguration, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f) // HL_error_in_struct
+	// START ReadLineReadConfiguration OMIT
+	header, _, err := reader.ReadLine() // HL_error_in_struct
+	if err != nil {                     // HL_error_in_struct
+		return nil, fmt.Errorf("header: %w", TooFewLines(err)) // HL_error_in_struct
+	} // HL_error_in_struct
+	// HL_error_in_struct
+	body, _, err := reader.ReadLine() // HL_error_in_struct
+	if err != nil {                   // HL_error_in_struct
+		return nil, fmt.Errorf("body: %w", TooFewLines(err)) // HL_error_in_struct
+	} // HL_error_in_struct
+	// END ReadLineReadConfiguration OMIT
+
+	return NewRawConfiguration(filename, header, body, reader)
+}
+
+// END readConfiguration OMIT
+
+// START ReadConfigurationContext OMIT
+func ReadConfigurationContext(ctx context.Context, filename string) (*RawConfiguration, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buffered := bufio.NewReader(NewContextReader(ctx, f))
+	reader := NewErrorReader(buffered)
+	header := reader.Wrap(TooFewLinesIn("header")).ReadLineContext(ctx)
+	body := reader.Wrap(TooFewLinesIn("body")).ReadLineContext(ctx)
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewRawConfiguration(filename, header, body, buffered)
+}
+
+// END ReadConfigurationContext OMIT
+
+// START parseConfiguration OMIT
+func ParseConfiguration(configuration *RawConfiguration) (*Configuration, error) {
+	// START ErrorCheckerParseConfiguration OMIT
+	version, err := strconv.Atoi(string(configuration.Header)) // HL_check
+	if err != nil {                                            // HL_check
+		return nil, NewVersionError(err) // HL_check
+	} // HL_check
+
+	var data map[string]string                                      // HL_check
+	err = Decode(configuration.Encoding, configuration.Body, &data) // HL_check
+	if err != nil {                                                 // HL_check
+		return nil, NewBodySyntaxError(err) // HL_check
+	} // HL_check
+	// END ErrorCheckerParseConfiguration OMIT
+
+	return &Configuration{
+		Version: version,
+		Data:    data,
+	}, nil
+}
+
+// END parseConfiguration OMIT
+
+// START calculateCommands OMIT
+func CalculateCommands(configuration *Configuration) ([]string, error) {
+	var commands []string
+	// START MonadCalculateCommands OMIT
+	downCommands, err := CalculateDownCommands(configuration) // HL_monad
+	if err != nil {                                           // HL_monad
+		return nil, fmt.Errorf("%s: %w", Down, err) // HL_monad
+	} // HL_monad
+	commands = append(commands, downCommands...)
+
+	upCommands, err := CalculateUpCommands(configuration) // HL_monad
+	if err != nil {                                       // HL_monad
+		return nil, fmt.Errorf("%s: %w", Up, err) // HL_monad
+	} // HL_monad
+	commands = append(commands, upCommands...)
+	// END MonadCalculateCommands OMIT
+
+	return commands, nil
+}
+
+// END calculateCommands OMIT
+
+// START calculateDownCommands OMIT
+func CalculateDownCommands(configuration *Configuration) ([]string, error) {
+	switch mode := configuration.Data[Down]; mode {
+	case DHCP:
+		if configuration.Version < Latest {
+			return nil, &FeatureVersionError{Feature: "DHCP", Have: configuration.Version, Need: Latest}
+		}
+		return []string{
+			"pkill dhclient",
+			"ifdown eth0",
+		}, nil
+	case Static:
+		return []string{
+			"ifdown eth0",
+		}, nil
+	default:
+		return nil, &UnsupportedModeError{Direction: Down, Mode: mode}
+	}
+}
+
+// END calculateDownCommands OMIT
+
+// END OMIT
+
+// START calculateUpCommands OMIT
+func CalculateUpCommands(configuration *Configuration) ([]string, error) {
+	switch mode := configuration.Data[Up]; mode {
+	case DHCP:
+		if configuration.Version < Latest {
+			return nil, &FeatureVersionError{Feature: "DHCP", Have: configuration.Version, Need: Latest}
+		}
+		return []string{
+			"ifup eth0",
+			"dhclient",
+		}, nil
+	case Static:
+		return []string{
+			"ifdown eth0",
+		}, nil
+	default:
+		return nil, &UnsupportedModeError{Direction: Up, Mode: mode}
+	}
+}
+
+// END calculateUpCommands OMIT
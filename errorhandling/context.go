@@ -0,0 +1,137 @@
+package errorhandling
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// START DoContext OMIT
+func DoContext(ctx context.Context, fs ...func(context.Context) error) error {
+	for _, f := range fs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// END DoContext OMIT
+
+// START Timeout OMIT
+func Timeout(d time.Duration, f func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := timeout(ctx, d, func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, f(ctx)
+		})
+		return err
+	}
+}
+
+// END Timeout OMIT
+
+// timeout runs f with a context expiring after d. f must return once the
+// context is done, timeout waits for it rather than leaving it running in
+// the background. A step which expired is reported as an error wrapping
+// context.DeadlineExceeded, whatever it returned, while the error of the
+// parent context is returned as is.
+func timeout[T any](parent context.Context, d time.Duration, f func(context.Context) (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(parent, d)
+	defer cancel()
+
+	value, err := f(ctx)
+	if ctx.Err() == nil {
+		return value, err
+	}
+	var zero T
+	if err := parent.Err(); err != nil {
+		return zero, err
+	}
+	return zero, fmt.Errorf("step timed out after %v: %w", d, ctx.Err())
+}
+
+// START FuncContext OMIT
+type FuncContext func(context.Context, interface{}) (interface{}, error)
+
+// END FuncContext OMIT
+
+// START EitherContext OMIT
+func EitherContext(f Func) FuncContext {
+	return func(_ context.Context, x interface{}) (interface{}, error) {
+		return f(x)
+	}
+}
+
+// END EitherContext OMIT
+
+// START EitherWrapContext OMIT
+func EitherWrapContext(f interface{}) FuncContext {
+	wrapped, err := EitherFuncContext(f)
+	if err != nil {
+		return func(context.Context, interface{}) (interface{}, error) {
+			return nil, err
+		}
+	}
+	return wrapped
+}
+
+// END EitherWrapContext OMIT
+
+// START DoEitherContext OMIT
+func DoEitherContext(ctx context.Context, x interface{}, fs ...FuncContext) (interface{}, error) {
+	var err error
+	for _, f := range fs {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		if x, err = f(ctx, x); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// END DoEitherContext OMIT
+
+// START TimeoutEither OMIT
+func TimeoutEither(d time.Duration, f FuncContext) FuncContext {
+	return func(ctx context.Context, x interface{}) (interface{}, error) {
+		return timeout(ctx, d, func(ctx context.Context) (interface{}, error) {
+			return f(ctx, x)
+		})
+	}
+}
+
+// END TimeoutEither OMIT
+
+// START ContextReader OMIT
+type ContextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+// END ContextReader OMIT
+
+// START NewContextReader OMIT
+func NewContextReader(ctx context.Context, reader io.Reader) *ContextReader {
+	return &ContextReader{
+		ctx:    ctx,
+		reader: reader,
+	}
+}
+
+// END NewContextReader OMIT
+
+// START ContextReader Read OMIT
+func (r *ContextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// END ContextReader Read OMIT
@@ -0,0 +1,26 @@
+package errorhandling
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	returned := false
+	step := Timeout(time.Millisecond, func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		returned = true
+		return ctx.Err()
+	})
+
+	err := step(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if !returned {
+		t.Error("the step is still running after the timeout")
+	}
+}
@@ -0,0 +1,88 @@
+// Package errorhandling contains the configuration pipeline used throughout
+// the "Less verbose error handling" slides together with every error handling
+// helper the slides introduce: ErrorReader, ErrorParser, ErrorChecker and
+// the accumulating ValidationChecker (errors stored in a struct),
+// ConfigurationCalculator, Do, DoConcurrent and DoConcurrentAll (monad),
+// CommandGetter, EitherWrap and DoEither (generic monad), Result and Pipe2,
+// Pipe3 and PipeN (generic monad with type parameters) and Check and Handle
+// (emulation of the Go 2 draft design).
+//
+// The stages of the pipeline (ReadConfiguration, ParseConfiguration,
+// CalculateCommands) are written in the standard, verbose way so that each
+// demo program can replace one of them with the pattern it presents.
+//
+// Errors are wrapped with %w on their way up. A function annotates them with
+// the details only it knows, like the field that failed to parse, and its
+// caller with the name of the step, so every helper has a way to name steps:
+// Step for Do, StepEither for DoEither, StepFunc for Pipe2, Pipe3 and Check,
+// CheckStep and HandleStep for check and handle and ErrorChecker.Step and
+// ErrorReader.Step, which like their Wrap name the next call only.
+//
+// EitherWrap checks the signature of the function it wraps once and reports
+// what it cannot call, like a function with several values besides the
+// error or an argument of the wrong type, as an error wrapping ErrSignature
+// or ErrArgument instead of panicking in reflect. EitherFunc returns that
+// error right away.
+//
+// Compose goes further and checks a whole chain when it is built: every
+// stage has to take the result of the one before, the first the input and
+// the last has to return the requested result, otherwise a *ChainError names
+// the stage and both types.
+//
+// As turns the interface{} result of DoEither back into its type, AsSlice,
+// AsMap and AsPointer also convert slices, maps and values holding the right
+// types. A value of another type is a *TypeError, never a silent nil.
+//
+// DoContext, DoEitherContext and GetCommandsFromFileContext stop between
+// steps once their context is done, ContextReader while reading lines, and
+// Timeout and TimeoutEither give a single step its own deadline. The step
+// has to return once its context is done, an expired step returns an error
+// wrapping context.DeadlineExceeded, a failed one its own error.
+//
+// DoTransaction undoes the completed steps in reverse order when a step
+// fails, reporting failed undos together with the original error in a
+// *CompensationError.
+//
+// A failed check records the stack of its call site, Handle returns the
+// error as a *StackError which prints it with %+v and keeps it through
+// HandleStep and HandleWrap. Checking an error which already carries a stack
+// keeps the innermost one. CaptureStackTraces turns this off.
+//
+// HandleWith runs handlers like stacked defers, the last one first, and only
+// while there is an error, so a handler returning nil, like LogAndContinue,
+// ends the chain. Deferring HandleWith again below a resource adds handlers
+// for the rest of the function, the way handle blocks of the Go 2 draft
+// design are chained. Annotate, Replace, Cleanup and LogAndContinue build
+// the common handlers, any func(error) error like TooFewLines is one too.
+//
+// The panic of check is an *Error wrapping the checked error. Handle only
+// recovers checks outside of any Scope and Scope.Handle only those made with
+// the scope. Plain checks of a helper forgetting its own handle still reach
+// the Handle of its caller, checks of a scope cannot pass their error to an
+// unrelated one. Other panics continue unchanged, so recover still sees
+// values like http.ErrAbortHandler and the runtime prints the stack of the
+// original panic, runtime.Goexit passes through every handle.
+//
+// A check in a goroutine started with Group.Go is panicked again by
+// Group.Wait in the goroutine waiting for it, so the handle of that function
+// returns it. With several failed goroutines the one started first wins,
+// Wait forgets them all, so the group can start new goroutines afterwards.
+//
+// Retry, RetryContext and RetryEither repeat a step with exponential backoff
+// and jitter as described by a RetryPolicy, and report every failed attempt
+// in a *RetryError.
+//
+// The body is decoded by the encoding named after the version in the header,
+// like "2 ini", or registered for the extension of the file, JSON otherwise.
+// JSON and key=value lines, with INI sections, are built in, YAML and TOML
+// decoders are added with RegisterEncoding. A body in an encoding without a
+// decoder fails with ErrUnsupportedEncoding.
+//
+// Every failure of the pipeline can be told apart with errors.Is and
+// errors.As: ErrTooFewLines, *VersionError, *BodySyntaxError,
+// *UnsupportedModeError and *FeatureVersionError, whatever the encoding.
+// Variants calling the standard library themselves convert its errors with
+// TooFewLines, NewVersionError and NewBodySyntaxError, passed to
+// ErrorChecker.Wrap, WrapFunc or HandleWrap where the error is not at hand,
+// and ErrorReader.Wrap takes TooFewLinesIn to name the missing line.
+package errorhandling
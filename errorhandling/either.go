@@ -0,0 +1,182 @@
+package errorhandling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrSignature is returned for functions EitherWrap cannot wrap.
+var ErrSignature = errors.New("unsupported signature")
+
+// ErrArgument is returned by a wrapped function given a value it cannot be
+// called with.
+var ErrArgument = errors.New("unsupported argument")
+
+var (
+	contextType = reflect.TypeFor[context.Context]()
+	errorType   = reflect.TypeFor[error]()
+)
+
+// reflectFunc is a function checked to be callable by DoEither. It takes
+// x as its only parameter, ignores it if it has none and takes the elements
+// of an []interface{} as its parameters if it has several or is variadic,
+// after a context.Context for DoEitherContext. It has at most one result
+// besides a trailing error, functions without one pass x on.
+type reflectFunc struct {
+	f        reflect.Value
+	in       []reflect.Type
+	variadic bool
+	context  bool
+	value    bool
+	err      bool
+}
+
+func newReflectFunc(f interface{}, withContext bool) (*reflectFunc, error) {
+	v := reflect.ValueOf(f)
+	if v.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%w: %T is not a function", ErrSignature, f)
+	}
+	if v.IsNil() {
+		return nil, fmt.Errorf("%w: nil %s", ErrSignature, v.Type())
+	}
+
+	typ := v.Type()
+	w := &reflectFunc{f: v, variadic: typ.IsVariadic(), context: withContext}
+	for n := 0; n < typ.NumIn(); n++ {
+		w.in = append(w.in, typ.In(n))
+	}
+	if withContext {
+		if len(w.in) == 0 || w.in[0] != contextType || w.variadic && len(w.in) == 1 {
+			return nil, fmt.Errorf("%w: %s does not take a context.Context first", ErrSignature, typ)
+		}
+		w.in = w.in[1:]
+	}
+
+	switch {
+	case typ.NumOut() == 1:
+		w.err = typ.Out(0) == errorType
+		w.value = !w.err
+	case typ.NumOut() == 2:
+		if typ.Out(1) != errorType {
+			return nil, fmt.Errorf("%w: the last result of %s is not an error", ErrSignature, typ)
+		}
+		w.value, w.err = true, true
+	case typ.NumOut() > 2:
+		return nil, fmt.Errorf("%w: %s has more than one result besides the error", ErrSignature, typ)
+	}
+	return w, nil
+}
+
+// START reflectFunc call OMIT
+func (w *reflectFunc) call(ctx context.Context, x interface{}) (interface{}, error) {
+	args, err := w.args(x)
+	if err != nil {
+		return nil, err
+	}
+	if w.context {
+		args = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, args...)
+	}
+
+	out := w.f.Call(args)
+	result := x
+	if w.value {
+		result = value(out[0])
+	}
+	if w.err {
+		err, _ = out[len(out)-1].Interface().(error)
+	}
+	return result, err
+}
+
+// END reflectFunc call OMIT
+
+func (w *reflectFunc) args(x interface{}) ([]reflect.Value, error) {
+	switch {
+	case len(w.in) == 0:
+		return nil, nil
+	case len(w.in) == 1 && !w.variadic:
+		arg, err := w.arg(w.in[0], x)
+		return []reflect.Value{arg}, err
+	}
+
+	xs, ok := x.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: %s takes its arguments as []interface{}, not %T", ErrArgument, w.f.Type(), x)
+	}
+	fixed := len(w.in)
+	if w.variadic {
+		fixed--
+	}
+	if len(xs) < fixed || !w.variadic && len(xs) > fixed {
+		return nil, fmt.Errorf("%w: %d arguments for %s", ErrArgument, len(xs), w.f.Type())
+	}
+
+	args := make([]reflect.Value, len(xs))
+	for n, x := range xs {
+		typ := w.in[min(n, len(w.in)-1)]
+		if n >= fixed {
+			typ = typ.Elem()
+		}
+		var err error
+		if args[n], err = w.arg(typ, x); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+func (w *reflectFunc) arg(typ reflect.Type, x interface{}) (reflect.Value, error) {
+	if x == nil {
+		if nillable(typ) {
+			return reflect.Zero(typ), nil
+		}
+		return reflect.Value{}, fmt.Errorf("%w: nil %s for %s", ErrArgument, typ, w.f.Type())
+	}
+
+	v := reflect.ValueOf(x)
+	if !v.Type().AssignableTo(typ) {
+		return reflect.Value{}, fmt.Errorf("%w: %s instead of %s for %s", ErrArgument, v.Type(), typ, w.f.Type())
+	}
+	return v, nil
+}
+
+// value returns v as an interface{}, nil for nil pointers, slices and so on,
+// so that a step returning a nil *Configuration is seen as returning nil.
+func value(v reflect.Value) interface{} {
+	if nillable(v.Type()) && v.IsNil() {
+		return nil
+	}
+	return v.Interface()
+}
+
+func nillable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// EitherFunc wraps f like EitherWrap, but reports a function it cannot call
+// right away.
+func EitherFunc(f interface{}) (Func, error) {
+	w, err := newReflectFunc(f, false)
+	if err != nil {
+		return nil, err
+	}
+	return func(x interface{}) (interface{}, error) {
+		return w.call(context.Background(), x)
+	}, nil
+}
+
+// EitherFuncContext wraps f like EitherWrapContext, but reports a function
+// it cannot call right away.
+func EitherFuncContext(f interface{}) (FuncContext, error) {
+	w, err := newReflectFunc(f, true)
+	if err != nil {
+		return nil, err
+	}
+	return w.call, nil
+}
@@ -0,0 +1,128 @@
+package errorhandling
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type counter struct {
+	n int
+}
+
+func (c *counter) Add(n int) int {
+	c.n += n
+	return c.n
+}
+
+func TestEitherFunc(t *testing.T) {
+	errFailed := errors.New("failed")
+	var nilFunc func(int) int
+
+	tests := []struct {
+		name string
+		f    interface{}
+		x    interface{}
+		want interface{}
+		err  error
+	}{
+		{name: "value and error", f: strconv.Atoi, x: "42", want: 42},
+		{name: "failing", f: strconv.Atoi, x: "abc", err: strconv.ErrSyntax},
+		{name: "error only passes x on", f: func(int) error { return nil }, x: 7, want: 7},
+		{name: "error only failing", f: func(int) error { return errFailed }, x: 7, err: errFailed},
+		{name: "value only", f: func(n int) int { return n * 2 }, x: 21, want: 42},
+		{name: "no results", f: func(int) {}, x: 3, want: 3},
+
+		{name: "nil for a pointer", f: func(c *counter) bool { return c == nil }, x: nil, want: true},
+		{name: "nil for a map", f: func(m map[string]string) int { return len(m) }, x: nil, want: 0},
+		{name: "nil for an int", f: func(n int) int { return n }, x: nil, err: ErrArgument},
+		{name: "nil result", f: func(int) *counter { return nil }, x: 1, want: nil},
+		{name: "wrong type", f: func(n int) int { return n }, x: "1", err: ErrArgument},
+
+		{name: "zero arity ignores x", f: func() int { return 1 }, x: "ignored", want: 1},
+		{name: "zero arity error only", f: func() error { return nil }, x: "kept", want: "kept"},
+		{name: "zero arity failing", f: func() (int, error) { return 0, errFailed }, x: nil, err: errFailed},
+
+		{name: "several parameters", f: strings.Repeat, x: []interface{}{"ab", 2}, want: "abab"},
+		{name: "too many arguments", f: strings.Repeat, x: []interface{}{"ab", 2, 3}, err: ErrArgument},
+		{name: "too few arguments", f: strings.Repeat, x: []interface{}{"ab"}, err: ErrArgument},
+		{name: "not an []interface{}", f: strings.Repeat, x: "ab", err: ErrArgument},
+		{name: "wrong argument type", f: strings.Repeat, x: []interface{}{"ab", "2"}, err: ErrArgument},
+
+		{name: "slice parameter", f: strings.Join, x: []interface{}{[]string{"a", "b"}, "-"}, want: "a-b"},
+		{name: "variadic elements", f: func(sep string, xs ...string) string { return strings.Join(xs, sep) }, x: []interface{}{"-", "a", "b", "c"}, want: "a-b-c"},
+		{name: "variadic without elements", f: func(sep string, xs ...string) int { return len(xs) }, x: []interface{}{"-"}, want: 0},
+		{name: "variadic only", f: func(xs ...int) int { return len(xs) }, x: []interface{}{1, 2, 3}, want: 3},
+		{name: "variadic missing fixed", f: func(sep string, xs ...string) int { return len(xs) }, x: []interface{}{}, err: ErrArgument},
+		{name: "variadic wrong element", f: func(xs ...int) int { return len(xs) }, x: []interface{}{1, "2"}, err: ErrArgument},
+
+		{name: "method value", f: (&counter{n: 1}).Add, x: 2, want: 3},
+		{name: "method expression", f: (*counter).Add, x: []interface{}{&counter{n: 1}, 2}, want: 3},
+
+		{name: "not a function", f: 42, err: ErrSignature},
+		{name: "nil function", f: nilFunc, err: ErrSignature},
+		{name: "last result not an error", f: func() (int, int) { return 0, 0 }, err: ErrSignature},
+		{name: "too many results", f: func() (int, int, error) { return 0, 0, nil }, err: ErrSignature},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := EitherFunc(test.f)
+			if err == nil {
+				var got interface{}
+				got, err = f(test.x)
+				if err == nil && !reflect.DeepEqual(got, test.want) {
+					t.Errorf("got %#v, want %#v", got, test.want)
+				}
+			}
+			if test.err == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestEitherFuncContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	tests := []struct {
+		name string
+		f    interface{}
+		x    interface{}
+		want interface{}
+		err  error
+	}{
+		{name: "context only", f: func(ctx context.Context) interface{} { return ctx.Value(key{}) }, x: nil, want: "value"},
+		{name: "context and value", f: func(ctx context.Context, n int) (int, error) { return n + 1, ctx.Err() }, x: 1, want: 2},
+		{name: "context and several", f: func(ctx context.Context, a, b int) int { return a + b }, x: []interface{}{1, 2}, want: 3},
+		{name: "no context", f: strconv.Atoi, err: ErrSignature},
+		{name: "no parameters", f: func() error { return nil }, err: ErrSignature},
+		{name: "variadic context", f: func(ctxs ...context.Context) error { return nil }, err: ErrSignature},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := EitherFuncContext(test.f)
+			if err == nil {
+				var got interface{}
+				got, err = f(ctx, test.x)
+				if err == nil && !reflect.DeepEqual(got, test.want) {
+					t.Errorf("got %#v, want %#v", got, test.want)
+				}
+			}
+			if test.err == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package errorhandling
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	JSON = "json"
+	INI  = "ini"
+	KV   = "kv"
+	YAML = "yaml"
+	TOML = "toml"
+)
+
+// ErrUnsupportedEncoding is returned for a body in an encoding without a
+// registered decoder.
+var ErrUnsupportedEncoding = errors.New("unsupported encoding")
+
+// START Decoder OMIT
+type Decoder func(data []byte, v interface{}) error
+
+// END Decoder OMIT
+
+var encodings = struct {
+	sync.RWMutex
+	decoders   map[string]Decoder
+	extensions map[string]string
+}{
+	decoders: map[string]Decoder{
+		JSON: json.Unmarshal,
+		INI:  DecodeINI,
+		KV:   DecodeINI,
+		// YAML and TOML need a decoder registered with RegisterEncoding,
+		// like yaml.Unmarshal or toml.Unmarshal.
+		YAML: nil,
+		TOML: nil,
+	},
+	extensions: map[string]string{
+		".json": JSON,
+		".ini":  INI,
+		".conf": INI,
+		".kv":   KV,
+		".yaml": YAML,
+		".yml":  YAML,
+		".toml": TOML,
+	},
+}
+
+// START RegisterEncoding OMIT
+func RegisterEncoding(name string, decode Decoder, extensions ...string) {
+	encodings.Lock()
+	defer encodings.Unlock()
+
+	encodings.decoders[name] = decode
+	for _, extension := range extensions {
+		encodings.extensions[extension] = name
+	}
+}
+
+// END RegisterEncoding OMIT
+
+// START Decode OMIT
+func Decode(encoding string, data []byte, v interface{}) error {
+	if encoding == "" {
+		encoding = JSON
+	}
+
+	encodings.RLock()
+	decode := encodings.decoders[encoding]
+	encodings.RUnlock()
+
+	if decode == nil {
+		return fmt.Errorf("%w %q", ErrUnsupportedEncoding, encoding)
+	}
+	return decode(data, v)
+}
+
+// END Decode OMIT
+
+// EncodingOf returns the encoding of the body of a configuration file, named
+// after the version in the header, like "2 ini", or by the extension of the
+// file, together with the version. It is JSON for neither.
+func EncodingOf(filename string, header []byte) (version []byte, encoding string) {
+	version, name, found := bytes.Cut(header, []byte(" "))
+	if found {
+		return version, string(bytes.TrimSpace(name))
+	}
+
+	encodings.RLock()
+	defer encodings.RUnlock()
+	return version, encodings.extensions[filepath.Ext(filename)]
+}
+
+// NewRawConfiguration returns the configuration with the given header and
+// body lines, the version and encoding taken from the header like by
+// EncodingOf. For every encoding but JSON, which is on a single line, the
+// body continues with the rest of the input, if any, so that a JSON body may
+// be followed by comments.
+func NewRawConfiguration(filename string, header, body []byte, rest io.Reader) (*RawConfiguration, error) {
+	// Both lines may point into the buffer of the reader.
+	body = bytes.Clone(body)
+	version, encoding := EncodingOf(filename, bytes.Clone(header))
+	if rest != nil && encoding != JSON && encoding != "" {
+		more, err := io.ReadAll(rest)
+		if err != nil {
+			return nil, fmt.Errorf("body: %w", err)
+		}
+		if len(bytes.TrimSpace(more)) > 0 {
+			body = append(append(body, '\n'), more...)
+		}
+	}
+
+	return &RawConfiguration{
+		Header:   version,
+		Body:     body,
+		Encoding: encoding,
+	}, nil
+}
+
+// DecodeINI decodes key=value lines into a *map[string]string. Blank lines,
+// lines starting with # or ; and [section] headers are skipped, so the keys
+// of every section end up in the same map, and quoted values are unquoted.
+func DecodeINI(data []byte, v interface{}) error {
+	result, ok := v.(*map[string]string)
+	if !ok {
+		return fmt.Errorf("ini: cannot decode into %T", v)
+	}
+	if *result == nil {
+		*result = make(map[string]string)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", line[0] == '#', line[0] == ';':
+			continue
+		case line[0] == '[' && line[len(line)-1] == ']':
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("ini: line %d: missing = in %q", n, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return fmt.Errorf("ini: line %d: %w", n, err)
+			}
+			value = unquoted
+		}
+		(*result)[key] = value
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,84 @@
+package errorhandling
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+)
+
+// START ErrorReader  OMIT
+type ErrorReader struct {
+	err    error
+	wrap   func(error) error
+	reader *bufio.Reader
+}
+
+// END ErrorReader  OMIT
+
+// START NewErrorReader OMIT
+func NewErrorReader(reader *bufio.Reader) *ErrorReader {
+	return &ErrorReader{
+		reader: reader,
+	}
+}
+
+// END NewErrorReader OMIT
+
+// START ErrorReader Err OMIT
+func (r *ErrorReader) Err() error {
+	return r.err
+}
+
+// END ErrorReader Err OMIT
+
+// START ErrorReader Step OMIT
+func (r *ErrorReader) Step(name string) *ErrorReader {
+	return r.Wrap(func(err error) error {
+		return fmt.Errorf("%s: %w", name, err)
+	})
+}
+
+// END ErrorReader Step OMIT
+
+// START ErrorReader Wrap OMIT
+func (r *ErrorReader) Wrap(wrap func(error) error) *ErrorReader {
+	if r.err == nil {
+		r.wrap = wrap
+	}
+	return r
+}
+
+// END ErrorReader Wrap OMIT
+
+// START ErrorReader set OMIT
+func (r *ErrorReader) set(err error) {
+	if err != nil && r.wrap != nil {
+		err = r.wrap(err)
+	}
+	r.err, r.wrap = err, nil
+}
+
+// END ErrorReader set OMIT
+
+// START ErrorReader ReadLine OMIT
+func (r *ErrorReader) ReadLine() []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	result, _, err := r.reader.ReadLine()
+	r.set(err)
+	return result
+}
+
+// END ErrorReader ReadLine OMIT
+
+// START ErrorReader ReadLineContext OMIT
+func (r *ErrorReader) ReadLineContext(ctx context.Context) []byte {
+	if err := ctx.Err(); r.err == nil && err != nil {
+		r.set(err)
+	}
+	return r.ReadLine()
+}
+
+// END ErrorReader ReadLineContext OMIT
@@ -0,0 +1,95 @@
+package errorhandling
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTooFewLines is returned when a configuration file ends before its
+// header or body line.
+var ErrTooFewLines = errors.New("too few lines")
+
+// TooFewLines returns ErrTooFewLines for the end of the input and err
+// otherwise.
+func TooFewLines(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrTooFewLines
+	}
+	return err
+}
+
+// TooFewLinesIn returns a wrap for the error of reading the named line, like
+// ReadConfiguration annotates it: TooFewLines of the error with the name.
+func TooFewLinesIn(line string) func(error) error {
+	return func(err error) error {
+		return fmt.Errorf("%s: %w", line, TooFewLines(err))
+	}
+}
+
+// VersionError is returned when the header is not a version number.
+type VersionError struct {
+	Err error
+}
+
+// NewVersionError returns a *VersionError wrapping err, or nil if err is nil.
+func NewVersionError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &VersionError{Err: err}
+}
+
+func (e *VersionError) Error() string {
+	return "version: " + e.Err.Error()
+}
+
+func (e *VersionError) Unwrap() error {
+	return e.Err
+}
+
+// BodySyntaxError is returned when the body cannot be decoded by its
+// encoding into a map of strings.
+type BodySyntaxError struct {
+	Err error
+}
+
+// NewBodySyntaxError returns a *BodySyntaxError wrapping err, or nil if err
+// is nil.
+func NewBodySyntaxError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &BodySyntaxError{Err: err}
+}
+
+func (e *BodySyntaxError) Error() string {
+	return "data: " + e.Err.Error()
+}
+
+func (e *BodySyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedModeError is returned for a mode other than DHCP and Static.
+// The message leaves the direction to the name of the step, Down or Up.
+type UnsupportedModeError struct {
+	Direction string
+	Mode      string
+}
+
+func (e *UnsupportedModeError) Error() string {
+	return fmt.Sprintf("mode %q: unsupported configuration mode", e.Mode)
+}
+
+// FeatureVersionError is returned when a feature needs a newer version of
+// the configuration.
+type FeatureVersionError struct {
+	Feature string
+	Have    int
+	Need    int
+}
+
+func (e *FeatureVersionError) Error() string {
+	return fmt.Sprintf("version %d: %s not supported before version %d", e.Have, e.Feature, e.Need)
+}
@@ -0,0 +1,102 @@
+package errorhandling
+
+import (
+	"fmt"
+)
+
+// START CommandGetter OMIT
+type CommandGetter struct {
+	filename         string
+	rawConfiguration *RawConfiguration
+	configuration    *Configuration
+	commands         []string
+}
+
+// END CommandGetter OMIT
+
+// START NewCommandGetter OMIT
+func NewCommandGetter(filename string) *CommandGetter {
+	return &CommandGetter{
+		filename: filename,
+	}
+}
+
+// END NewCommandGetter OMIT
+
+// START CommandGetter GetCommands OMIT
+func (g *CommandGetter) GetCommands() []string {
+	return g.commands
+}
+
+// END CommandGetter GetCommands OMIT
+
+// START CommandGetter readConfiguration OMIT
+func (g *CommandGetter) ReadConfiguration() error {
+	var err error
+	g.rawConfiguration, err = ReadConfiguration(g.filename)
+	return err
+}
+
+// END CommandGetter readConfiguration OMIT
+
+// START CommandGetter parseConfiguration OMIT
+func (g *CommandGetter) ParseConfiguration() error {
+	var err error
+	g.configuration, err = ParseConfiguration(g.rawConfiguration)
+	return err
+}
+
+// END CommandGetter parseConfiguration OMIT
+
+// START CommandGetter calculateCommands OMIT
+func (g *CommandGetter) CalculateCommands() error {
+	var err error
+	g.commands, err = CalculateCommands(g.configuration)
+	return err
+}
+
+// END CommandGetter calculateCommands OMIT
+
+// START Func OMIT
+type Func func(interface{}) (interface{}, error)
+
+// END Func OMIT
+
+// START EitherWrap OMIT
+func EitherWrap(f interface{}) Func {
+	wrapped, err := EitherFunc(f)
+	if err != nil {
+		return func(interface{}) (interface{}, error) {
+			return nil, err
+		}
+	}
+	return wrapped
+}
+
+// END EitherWrap OMIT
+
+// START DoEither OMIT
+func DoEither(x interface{}, fs ...Func) (interface{}, error) {
+	var err error
+	for _, f := range fs {
+		if x, err = f(x); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// END DoEither OMIT
+
+// START StepEither OMIT
+func StepEither(name string, f Func) Func {
+	return func(x interface{}) (interface{}, error) {
+		y, err := f(x)
+		if err != nil {
+			return y, fmt.Errorf("%s: %w", name, err)
+		}
+		return y, nil
+	}
+}
+
+// END StepEither OMIT
@@ -0,0 +1,177 @@
+package errorhandling
+
+import (
+	"fmt"
+)
+
+// START Error  OMIT
+type Error struct {
+	err   error
+	stack []uintptr
+	scope *Scope
+}
+
+// END Error  OMIT
+
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// START NewError OMIT
+func NewError(err error) *Error {
+	return &Error{err: err, stack: stack(err)}
+}
+
+// END NewError OMIT
+
+// recovered returns the error to return from the function which recovered
+// e, a *StackError if the stack was captured.
+func (e *Error) recovered() error {
+	if _, ok := e.err.(*StackError); ok || e.stack == nil {
+		return e.err
+	}
+	return &StackError{err: e.err, stack: e.stack}
+}
+
+// START handle OMIT
+// Handle stores the error of a failed check in err, it must be deferred by
+// the function declaring err. Checks outside of a Scope are not tied to the
+// function which made them: a check in a callee without its own Handle is
+// recovered here too, use a Scope to keep such checks out.
+func Handle(err *error) {
+	if r := recover(); r != nil {
+		if recoveredError, ok := r.(*Error); ok && recoveredError.scope == nil {
+			*err = recoveredError.recovered()
+		} else {
+			panic(r)
+		}
+	}
+}
+
+// END handle OMIT
+
+// START HandleStep OMIT
+func HandleStep(err *error, name string) {
+	handle(recover(), nil, err, func(err error) error {
+		return fmt.Errorf("%s: %w", name, err)
+	})
+}
+
+// END HandleStep OMIT
+
+// START HandleWrap OMIT
+func HandleWrap(err *error, wrap func(error) error) {
+	handle(recover(), nil, err, wrap)
+}
+
+// END HandleWrap OMIT
+
+// handle stores the error of a recovered check of scope in err and applies
+// wrap to the error returned by the function, if any, keeping its stack on
+// top. Other panics continue with the same value, the runtime still prints
+// the stack of the original panic, runtime.Goexit is not seen by recover at
+// all.
+func handle(r interface{}, scope *Scope, err *error, wrap func(error) error) {
+	if r != nil {
+		recoveredError, ok := r.(*Error)
+		if !ok || recoveredError.scope != scope {
+			panic(r)
+		}
+		*err = recoveredError.recovered()
+	}
+	if *err == nil || wrap == nil {
+		return
+	}
+	if stackError, ok := (*err).(*StackError); ok {
+		if wrapped := wrap(stackError.err); wrapped != nil {
+			*err = &StackError{err: wrapped, stack: stackError.stack}
+		} else {
+			*err = nil
+		}
+	} else {
+		*err = wrap(*err)
+	}
+}
+
+// START check OMIT
+func Check[T any](x T, err error) T {
+	Check0(err)
+	return x
+}
+
+// END check OMIT
+
+// START Check0 OMIT
+func Check0(err error) {
+	if err != nil {
+		panic(NewError(err))
+	}
+}
+
+// END Check0 OMIT
+
+// START CheckStep OMIT
+func CheckStep(name string, err error) {
+	if err != nil {
+		panic(NewError(fmt.Errorf("%s: %w", name, err)))
+	}
+}
+
+// END CheckStep OMIT
+
+// START Check2 OMIT
+func Check2[A, B any](a A, b B, err error) (A, B) {
+	Check0(err)
+	return a, b
+}
+
+// END Check2 OMIT
+
+// START Scope OMIT
+type Scope struct {
+	// Pointers to distinct zero sized values may be equal.
+	_ byte
+}
+
+func NewScope() *Scope {
+	return new(Scope)
+}
+
+func (s *Scope) Handle(err *error) {
+	handle(recover(), s, err, nil)
+}
+
+func (s *Scope) Check0(err error) {
+	if err != nil {
+		checkError := NewError(err)
+		checkError.scope = s
+		panic(checkError)
+	}
+}
+
+func CheckIn[T any](x T, err error) func(s *Scope) T {
+	return func(s *Scope) T {
+		s.Check0(err)
+		return x
+	}
+}
+
+// END Scope OMIT
+
+// CheckIn2 is CheckIn for functions returning two values and an error.
+func CheckIn2[A, B any](a A, b B, err error) func(s *Scope) (A, B) {
+	return func(s *Scope) (A, B) {
+		s.Check0(err)
+		return a, b
+	}
+}
+
+// HandleWith runs the handlers like the HandleWith function, but only
+// recovers the checks of s.
+func (s *Scope) HandleWith(err *error, handlers ...Handler) {
+	handle(recover(), s, err, chain(handlers))
+}
@@ -0,0 +1,72 @@
+package errorhandling
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var errNoComma = errors.New("no comma")
+
+func split(s string) (string, string, error) {
+	a, b, ok := strings.Cut(s, ",")
+	if !ok {
+		return "", "", errNoComma
+	}
+	return a, b, nil
+}
+
+// parsePair parses a pair of numbers like "1,2".
+func parsePair(s string) (x, y int, err error) {
+	defer Handle(&err)
+
+	a, b := Check2(split(s))
+	x = Check(strconv.Atoi(a))
+	y = Check(strconv.Atoi(b))
+	return x, y, nil
+}
+
+func TestCheck(t *testing.T) {
+	x, y, err := parsePair("1,2")
+	if err != nil || x != 1 || y != 2 {
+		t.Errorf("got %d, %d, %v, want 1, 2", x, y, err)
+	}
+
+	tests := map[string]error{
+		"1":   errNoComma,
+		"x,2": strconv.ErrSyntax,
+		"1,y": strconv.ErrSyntax,
+	}
+	for s, want := range tests {
+		if _, _, err := parsePair(s); !errors.Is(err, want) {
+			t.Errorf("%s: got %v, want %v", s, err, want)
+		}
+	}
+}
+
+func TestHandleKeepsReturnedError(t *testing.T) {
+	errReturned := errors.New("returned")
+	f := func() (err error) {
+		defer Handle(&err)
+		Check0(nil)
+		return errReturned
+	}
+	if err := f(); err != errReturned {
+		t.Errorf("got %v, want %v", err, errReturned)
+	}
+}
+
+func TestHandleForeignPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("got panic %v, want %v", r, http.ErrAbortHandler)
+		}
+	}()
+
+	func() (err error) {
+		defer HandleStep(&err, "step")
+		panic(http.ErrAbortHandler)
+	}()
+}
@@ -0,0 +1,78 @@
+package errorhandling
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// START Group OMIT
+type Group struct {
+	// RecoverPanics forwards every panic of the goroutines to Wait, not only
+	// failed checks, the others crash the program otherwise. They are
+	// forwarded like failed checks, so the Handle of the function calling
+	// Wait returns them as a *PanicError.
+	RecoverPanics bool
+
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	panics []interface{}
+}
+
+// END Group OMIT
+
+// START Group Go OMIT
+func (g *Group) Go(f func()) {
+	g.mu.Lock()
+	n := len(g.panics)
+	g.panics = append(g.panics, nil)
+	g.mu.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.recover(n)
+		f()
+	}()
+}
+
+// END Group Go OMIT
+
+// recover stores the panic of the n-th goroutine. runtime.Goexit is not
+// seen by recover and just ends the goroutine.
+func (g *Group) recover(n int) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if _, ok := r.(*Error); !ok {
+		if !g.RecoverPanics {
+			panic(r)
+		}
+		panicError, ok := r.(*PanicError)
+		if !ok {
+			panicError = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+		r = &Error{err: panicError}
+	}
+
+	g.mu.Lock()
+	g.panics[n] = r
+	g.mu.Unlock()
+}
+
+// START Group Wait OMIT
+func (g *Group) Wait() {
+	g.wg.Wait()
+	g.mu.Lock()
+	panics := g.panics
+	g.panics = nil
+	g.mu.Unlock()
+
+	for _, r := range panics {
+		if r != nil {
+			panic(r)
+		}
+	}
+}
+
+// END Group Wait OMIT
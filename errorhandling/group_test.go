@@ -0,0 +1,75 @@
+package errorhandling
+
+import (
+	"errors"
+	"testing"
+)
+
+// waitGroup runs a goroutine for each of the results, checking errors and
+// panicking with other values.
+func waitGroup(recoverPanics bool, results ...interface{}) (err error) {
+	defer Handle(&err)
+
+	g := Group{RecoverPanics: recoverPanics}
+	for _, result := range results {
+		g.Go(func() {
+			if err, ok := result.(error); ok {
+				Check0(err)
+			} else if result != nil {
+				panic(result)
+			}
+		})
+	}
+	g.Wait()
+	return nil
+}
+
+func TestGroupWait(t *testing.T) {
+	errFirst := errors.New("first")
+
+	t.Run("check", func(t *testing.T) {
+		err := waitGroup(false, nil, errFirst)
+		if !errors.Is(err, errFirst) {
+			t.Errorf("got %v, want %v", err, errFirst)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		err := waitGroup(true, "boom", errFirst)
+		var panicError *PanicError
+		if !errors.As(err, &panicError) || panicError.Value != "boom" {
+			t.Fatalf("got %v, want the panic of the first goroutine", err)
+		}
+		if len(panicError.Stack) == 0 {
+			t.Error("the stack of the panic is lost")
+		}
+		if got := panicError.Error(); got != "boom" {
+			t.Errorf("got message %q, want only the value", got)
+		}
+	})
+}
+
+func TestGroupWaitAgain(t *testing.T) {
+	errFailed := errors.New("failed")
+	var g Group
+	wait := func(check bool) (err error) {
+		defer Handle(&err)
+		if check {
+			g.Go(func() { Check0(errFailed) })
+		}
+		g.Wait()
+		return nil
+	}
+
+	if err := wait(true); !errors.Is(err, errFailed) {
+		t.Fatalf("got %v, want %v", err, errFailed)
+	}
+	if err := wait(false); err != nil {
+		t.Errorf("got %v from the second Wait, want nil", err)
+	}
+
+	g.Go(func() {})
+	if err := wait(false); err != nil {
+		t.Errorf("got %v after reusing the group, want nil", err)
+	}
+}
@@ -0,0 +1,68 @@
+package errorhandling
+
+import (
+	"errors"
+	"fmt"
+)
+
+// START Handler OMIT
+type Handler func(err error) error
+
+// END Handler OMIT
+
+// START HandleWith OMIT
+func HandleWith(err *error, handlers ...Handler) {
+	handle(recover(), nil, err, chain(handlers))
+}
+
+// END HandleWith OMIT
+
+// START chain OMIT
+func chain(handlers []Handler) func(error) error {
+	return func(err error) error {
+		for n := len(handlers) - 1; n >= 0 && err != nil; n-- {
+			err = handlers[n](err)
+		}
+		return err
+	}
+}
+
+// END chain OMIT
+
+// START Annotate OMIT
+func Annotate(message string) Handler {
+	return func(err error) error {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+}
+
+// END Annotate OMIT
+
+// Replace returns a handler returning replacement for errors matching target
+// and other errors unchanged.
+func Replace(target, replacement error) Handler {
+	return func(err error) error {
+		if errors.Is(err, target) {
+			return replacement
+		}
+		return err
+	}
+}
+
+// Cleanup returns a handler running f on error, a failure of f is joined
+// with the error.
+func Cleanup(f func() error) Handler {
+	return func(err error) error {
+		return errors.Join(err, f())
+	}
+}
+
+// START LogAndContinue OMIT
+func LogAndContinue(log func(err error)) Handler {
+	return func(err error) error {
+		log(err)
+		return nil
+	}
+}
+
+// END LogAndContinue OMIT
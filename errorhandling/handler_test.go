@@ -0,0 +1,164 @@
+package errorhandling
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var (
+	errReplaced = errors.New("replaced")
+	errCleanup  = errors.New("cleanup failed")
+)
+
+func getCommands(filename string, handlers ...Handler) (commands []string, err error) {
+	defer HandleWith(&err, handlers...)
+
+	rawConfiguration := Check(ReadConfiguration(filename))
+	configuration := Check(ParseConfiguration(rawConfiguration))
+	return Check(CalculateCommands(configuration)), nil
+}
+
+func isA[T error](err error) bool {
+	var target T
+	return errors.As(err, &target)
+}
+
+func TestHandleWithFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		is      error
+		as      func(error) bool
+	}{
+		{fixture: "valid"},
+		{fixture: "valid_kv"},
+		{fixture: "valid.ini"},
+		{fixture: "valid_commented"},
+		{fixture: "missing", is: fs.ErrNotExist},
+		{fixture: "not_enough_lines", is: ErrTooFewLines},
+		{fixture: "version_not_a_number", as: isA[*VersionError]},
+		{fixture: "invalid_version_and_json", as: isA[*VersionError]},
+		{fixture: "invalid_json", as: isA[*BodySyntaxError]},
+		{fixture: "invalid_ini", as: isA[*BodySyntaxError]},
+		{fixture: "valid.yaml", is: ErrUnsupportedEncoding, as: isA[*BodySyntaxError]},
+		{fixture: "incorrect_mode", as: isA[*UnsupportedModeError]},
+		{fixture: "incorrect_version", as: isA[*FeatureVersionError]},
+	}
+
+	for _, test := range tests {
+		filename := "../resources/" + test.fixture
+		fails := test.is != nil || test.as != nil
+		check := func(t *testing.T, err error) {
+			t.Helper()
+			if !fails {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if test.is != nil && !errors.Is(err, test.is) {
+				t.Errorf("%v is not %v", err, test.is)
+			}
+			if test.as != nil && !test.as(err) {
+				t.Errorf("%v (%T) is not of the expected type", err, err)
+			}
+		}
+
+		t.Run(test.fixture, func(t *testing.T) {
+			t.Run("order", func(t *testing.T) {
+				var order []string
+				record := func(name string) Handler {
+					return func(err error) error {
+						order = append(order, name)
+						return err
+					}
+				}
+
+				commands, err := getCommands(filename, record("first"), Annotate("annotated"), record("last"))
+				check(t, err)
+				if !fails {
+					if len(commands) == 0 {
+						t.Error("no commands")
+					}
+					if len(order) != 0 {
+						t.Errorf("handlers ran without an error: %v", order)
+					}
+					return
+				}
+				if want := []string{"last", "first"}; !slices.Equal(order, want) {
+					t.Errorf("handlers ran in order %v, want %v", order, want)
+				}
+				if !strings.HasPrefix(err.Error(), "annotated: ") {
+					t.Errorf("%q is not annotated", err)
+				}
+			})
+
+			t.Run("replace", func(t *testing.T) {
+				_, err := getCommands(filename, Replace(ErrTooFewLines, errReplaced))
+				if test.is == ErrTooFewLines {
+					if !errors.Is(err, errReplaced) || errors.Is(err, ErrTooFewLines) {
+						t.Errorf("got %v, want %v", err, errReplaced)
+					}
+					return
+				}
+				check(t, err)
+				if errors.Is(err, errReplaced) {
+					t.Errorf("%v was replaced", err)
+				}
+			})
+
+			t.Run("cleanup", func(t *testing.T) {
+				var cleanups int
+				_, err := getCommands(filename,
+					Cleanup(func() error {
+						cleanups++
+						return errCleanup
+					}),
+					Cleanup(func() error {
+						cleanups++
+						return nil
+					}),
+				)
+				check(t, err)
+				if !fails {
+					if cleanups != 0 {
+						t.Errorf("%d cleanups without an error", cleanups)
+					}
+					return
+				}
+				if cleanups != 2 {
+					t.Errorf("%d cleanups, want 2", cleanups)
+				}
+				if !errors.Is(err, errCleanup) {
+					t.Errorf("%v does not report the failed cleanup", err)
+				}
+			})
+
+			t.Run("continue", func(t *testing.T) {
+				var logged []error
+				var ran bool
+				_, err := getCommands(filename,
+					func(err error) error {
+						ran = true
+						return err
+					},
+					LogAndContinue(func(err error) { logged = append(logged, err) }),
+				)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if ran {
+					t.Error("handler before LogAndContinue ran")
+				}
+				if fails && len(logged) != 1 || !fails && len(logged) != 0 {
+					t.Errorf("logged %v", logged)
+				}
+			})
+		})
+	}
+}
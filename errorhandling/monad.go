@@ -0,0 +1,73 @@
+package errorhandling
+
+import (
+	"fmt"
+)
+
+// START ConfigurationCalculator OMIT
+type ConfigurationCalculator struct {
+	configuration *Configuration
+	downCommands  []string
+	upCommands    []string
+}
+
+// END ConfigurationCalculator OMIT
+
+// START NewConfigurationCalculator OMIT
+func NewConfigurationCalculator(configuration *Configuration) *ConfigurationCalculator {
+	return &ConfigurationCalculator{
+		configuration: configuration,
+	}
+}
+
+// END NewConfigurationCalculator OMIT
+
+// START ConfigurationCalculator GetCommands OMIT
+func (c *ConfigurationCalculator) GetCommands() []string {
+	commands := append([]string(nil), c.downCommands...)
+	return append(commands, c.upCommands...)
+}
+
+// END ConfigurationCalculator GetCommands OMIT
+
+// START ConfigurationCalculator calculateDownCommands OMIT
+func (c *ConfigurationCalculator) CalculateDownCommands() error {
+	var err error
+	c.downCommands, err = CalculateDownCommands(c.configuration)
+	return err
+}
+
+// END ConfigurationCalculator calculateDownCommands OMIT
+
+// START ConfigurationCalculator calculateUpCommands OMIT
+func (c *ConfigurationCalculator) CalculateUpCommands() error {
+	var err error
+	c.upCommands, err = CalculateUpCommands(c.configuration)
+	return err
+}
+
+// END ConfigurationCalculator calculateUpCommands OMIT
+
+// START Do OMIT
+func Do(fs ...func() error) error {
+	for _, f := range fs {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// END Do OMIT
+
+// START Step OMIT
+func Step(name string, f func() error) func() error {
+	return func() error {
+		if err := f(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	}
+}
+
+// END Step OMIT
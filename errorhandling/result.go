@@ -0,0 +1,127 @@
+package errorhandling
+
+import (
+	"fmt"
+)
+
+// START Result OMIT
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+// END Result OMIT
+
+// START NewResult OMIT
+func NewResult[T any](value T, err error) Result[T] {
+	if err != nil {
+		return Failure[T](err)
+	}
+	return Success(value)
+}
+
+// END NewResult OMIT
+
+func Success[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+func Failure[T any](err error) Result[T] {
+	return Result[T]{err: err}
+}
+
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+// START Result Then OMIT
+func (r Result[T]) Then(f func(T) (T, error)) Result[T] {
+	return Then(r, f)
+}
+
+// END Result Then OMIT
+
+// START Then OMIT
+func Then[T, U any](r Result[T], f func(T) (U, error)) Result[U] {
+	if r.err != nil {
+		return Failure[U](r.err)
+	}
+	return NewResult(f(r.value))
+}
+
+// END Then OMIT
+
+// START Map OMIT
+func Map[T, U any](r Result[T], f func(T) U) Result[U] {
+	if r.err != nil {
+		return Failure[U](r.err)
+	}
+	return Success(f(r.value))
+}
+
+// END Map OMIT
+
+// START FlatMap OMIT
+func FlatMap[T, U any](r Result[T], f func(T) Result[U]) Result[U] {
+	if r.err != nil {
+		return Failure[U](r.err)
+	}
+	return f(r.value)
+}
+
+// END FlatMap OMIT
+
+// START Pipe2 OMIT
+func Pipe2[A, B, C any](f func(A) (B, error), g func(B) (C, error)) func(A) (C, error) {
+	return func(a A) (C, error) {
+		return Then(NewResult(f(a)), g).Get()
+	}
+}
+
+// END Pipe2 OMIT
+
+// START Pipe3 OMIT
+func Pipe3[A, B, C, D any](f func(A) (B, error), g func(B) (C, error), h func(C) (D, error)) func(A) (D, error) {
+	return Pipe2(Pipe2(f, g), h)
+}
+
+// END Pipe3 OMIT
+
+// START PipeN OMIT
+func PipeN[T any](fs ...func(T) (T, error)) func(T) (T, error) {
+	return func(x T) (T, error) {
+		r := Success(x)
+		for _, f := range fs {
+			r = r.Then(f)
+		}
+		return r.Get()
+	}
+}
+
+// END PipeN OMIT
+
+// START StepFunc OMIT
+func StepFunc[T, U any](name string, f func(T) (U, error)) func(T) (U, error) {
+	return WrapFunc(func(err error) error {
+		return fmt.Errorf("%s: %w", name, err)
+	}, f)
+}
+
+// END StepFunc OMIT
+
+// START WrapFunc OMIT
+func WrapFunc[T, U any](wrap func(error) error, f func(T) (U, error)) func(T) (U, error) {
+	return func(x T) (U, error) {
+		y, err := f(x)
+		if err != nil {
+			return y, wrap(err)
+		}
+		return y, nil
+	}
+}
+
+// END WrapFunc OMIT
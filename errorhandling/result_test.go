@@ -0,0 +1,72 @@
+package errorhandling
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestResult(t *testing.T) {
+	errFailed := errors.New("failed")
+	double := func(n int) (int, error) { return n * 2, nil }
+
+	if got, err := Then(NewResult(strconv.Atoi("21")), double).Get(); err != nil || got != 42 {
+		t.Errorf("got %d, %v, want 42", got, err)
+	}
+	if got := Map(Success(2), strconv.Itoa); got.Err() != nil || got.value != "2" {
+		t.Errorf("got %+v, want 2", got)
+	}
+
+	called := false
+	failed := FlatMap(Failure[int](errFailed), func(int) Result[int] {
+		called = true
+		return Success(0)
+	}).Then(double)
+	if failed.Err() != errFailed || called {
+		t.Errorf("got %v after calling the next step: %v, want %v without", failed.Err(), called, errFailed)
+	}
+}
+
+func TestPipe(t *testing.T) {
+	errTooLarge := errors.New("too large")
+	half := func(n int) (int, error) { return n / 2, nil }
+	small := func(n int) (int, error) {
+		if n > 100 {
+			return 0, errTooLarge
+		}
+		return n, nil
+	}
+
+	pipe2 := Pipe2(strconv.Atoi, half)
+	if got, err := pipe2("84"); err != nil || got != 42 {
+		t.Errorf("Pipe2: got %d, %v, want 42", got, err)
+	}
+	if _, err := pipe2("x"); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Pipe2: got %v, want %v", err, strconv.ErrSyntax)
+	}
+
+	pipe3 := Pipe3(strconv.Atoi, small, func(n int) (string, error) { return strconv.Itoa(n * 2), nil })
+	if got, err := pipe3("21"); err != nil || got != "42" {
+		t.Errorf("Pipe3: got %q, %v, want 42", got, err)
+	}
+	if _, err := pipe3("210"); err != errTooLarge {
+		t.Errorf("Pipe3: got %v, want %v", err, errTooLarge)
+	}
+
+	var calls int
+	count := func(n int) (int, error) {
+		calls++
+		return n, nil
+	}
+	pipeN := PipeN(count, half, small, count)
+	if got, err := pipeN(168); err != nil || got != 84 || calls != 2 {
+		t.Errorf("PipeN: got %d, %v after %d calls, want 84 after 2", got, err, calls)
+	}
+	calls = 0
+	if _, err := pipeN(400); err != errTooLarge || calls != 1 {
+		t.Errorf("PipeN: got %v after %d calls, want %v after 1", err, calls, errTooLarge)
+	}
+	if got, err := PipeN[int]()(7); err != nil || got != 7 {
+		t.Errorf("PipeN: got %d, %v without steps, want 7", got, err)
+	}
+}
@@ -0,0 +1,174 @@
+package errorhandling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// START RetryPolicy OMIT
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, 3 if not positive.
+	Attempts int
+	// Delay is the backoff before the second attempt.
+	Delay time.Duration
+	// MaxDelay caps the backoff if positive.
+	MaxDelay time.Duration
+	// Multiplier grows the backoff after every attempt, 2 if not positive.
+	Multiplier float64
+	// Jitter is the fraction of every backoff chosen at random, from 0 to 1,
+	// the step fails with ErrInvalidPolicy otherwise.
+	Jitter float64
+	// Retryable decides which errors are worth another attempt, all if nil.
+	Retryable func(error) bool
+	// Clock waits for the backoff, the real clock if nil.
+	Clock Clock
+	// Rand returns numbers from [0, 1) for the jitter, math/rand if nil.
+	Rand func() float64
+}
+
+// END RetryPolicy OMIT
+
+// ErrInvalidPolicy is returned by a retried step whose RetryPolicy is out of
+// range, before the first attempt.
+var ErrInvalidPolicy = errors.New("invalid retry policy")
+
+// START Clock OMIT
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+// END Clock OMIT
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// START RetryError OMIT
+type RetryError struct {
+	Attempts int
+	Errs     []error
+}
+
+// END RetryError OMIT
+
+func (e *RetryError) Error() string {
+	messages := make([]string, len(e.Errs))
+	for n, err := range e.Errs {
+		messages[n] = err.Error()
+	}
+	attempts := "attempts"
+	if e.Attempts == 1 {
+		attempts = "attempt"
+	}
+	return fmt.Sprintf("%d %s failed: %s", e.Attempts, attempts, strings.Join(messages, "; "))
+}
+
+func (e *RetryError) Unwrap() []error {
+	return e.Errs
+}
+
+// START Retry OMIT
+func Retry(policy RetryPolicy, f func() error) func() error {
+	return func() error {
+		return policy.do(context.Background(), func(context.Context) error {
+			return f()
+		})
+	}
+}
+
+// END Retry OMIT
+
+// START RetryContext OMIT
+func RetryContext(policy RetryPolicy, f func(context.Context) error) func(context.Context) error {
+	return func(ctx context.Context) error {
+		return policy.do(ctx, f)
+	}
+}
+
+// END RetryContext OMIT
+
+// START RetryEither OMIT
+func RetryEither(policy RetryPolicy, f Func) Func {
+	return func(x interface{}) (interface{}, error) {
+		var y interface{}
+		err := policy.do(context.Background(), func(context.Context) error {
+			var err error
+			y, err = f(x)
+			return err
+		})
+		return y, err
+	}
+}
+
+// END RetryEither OMIT
+
+// do calls f until it succeeds, fails with an error which is not retryable,
+// runs out of attempts or ctx is done while waiting for the next attempt. A
+// context which is done already is returned without any attempt.
+func (p RetryPolicy) do(ctx context.Context, f func(context.Context) error) error {
+	if !(p.Jitter >= 0 && p.Jitter <= 1) {
+		return fmt.Errorf("%w: jitter %v is not from 0 to 1", ErrInvalidPolicy, p.Jitter)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	attempts := p.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	clock := p.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	var errs []error
+	for attempt := 1; ; attempt++ {
+		err := f(ctx)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+		if attempt == attempts || p.Retryable != nil && !p.Retryable(err) {
+			return &RetryError{Attempts: attempt, Errs: errs}
+		}
+
+		select {
+		case <-clock.After(p.backoff(attempt)):
+		case <-ctx.Done():
+			return &RetryError{Attempts: attempt, Errs: append(errs, ctx.Err())}
+		}
+	}
+}
+
+// backoff returns the delay after the given failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	delay := float64(p.Delay)
+	for n := 1; n < attempt; n++ {
+		delay *= multiplier
+		if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		random := rand.Float64
+		if p.Rand != nil {
+			random = p.Rand
+		}
+		delay -= delay * p.Jitter * random()
+	}
+	return time.Duration(delay)
+}
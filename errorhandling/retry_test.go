@@ -0,0 +1,150 @@
+package errorhandling
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock records the backoffs and lets every one of them pass at once,
+// unless it is blocked.
+type fakeClock struct {
+	delays  []time.Duration
+	blocked bool
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.delays = append(c.delays, d)
+	after := make(chan time.Time, 1)
+	if !c.blocked {
+		after <- time.Time{}
+	}
+	return after
+}
+
+// failing returns a step failing with err the first n times.
+func failing(n int, err error) (step func(context.Context) error, calls *int) {
+	calls = new(int)
+	return func(context.Context) error {
+		*calls++
+		if *calls <= n {
+			return err
+		}
+		return nil
+	}, calls
+}
+
+func TestRetryBackoff(t *testing.T) {
+	errFailed := errors.New("failed")
+	clock := &fakeClock{}
+	policy := RetryPolicy{
+		Attempts: 5,
+		Delay:    100 * time.Millisecond,
+		MaxDelay: time.Second,
+		Jitter:   0.5,
+		Clock:    clock,
+		Rand:     func() float64 { return 0.5 },
+	}
+
+	step, calls := failing(4, errFailed)
+	if err := RetryContext(policy, step)(context.Background()); err != nil {
+		t.Fatalf("got %v, want the fifth attempt to succeed", err)
+	}
+	if *calls != 5 {
+		t.Errorf("got %d attempts, want 5", *calls)
+	}
+	want := []time.Duration{75 * time.Millisecond, 150 * time.Millisecond, 300 * time.Millisecond, 600 * time.Millisecond}
+	if !reflect.DeepEqual(clock.delays, want) {
+		t.Errorf("got backoffs %v, want %v", clock.delays, want)
+	}
+
+	policy.Jitter = 0
+	policy.Multiplier = 4
+	clock.delays = nil
+	step, _ = failing(4, errFailed)
+	if err := RetryContext(policy, step)(context.Background()); err != nil {
+		t.Fatalf("got %v, want the fifth attempt to succeed", err)
+	}
+	want = []time.Duration{100 * time.Millisecond, 400 * time.Millisecond, time.Second, time.Second}
+	if !reflect.DeepEqual(clock.delays, want) {
+		t.Errorf("got capped backoffs %v, want %v", clock.delays, want)
+	}
+}
+
+func TestRetryAttempts(t *testing.T) {
+	errFailed := errors.New("failed")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		err      error
+		attempts int
+	}{
+		{"default attempts", RetryPolicy{}, errFailed, 3},
+		{"max attempts", RetryPolicy{Attempts: 2}, errFailed, 2},
+		{"not retryable", RetryPolicy{Attempts: 5, Retryable: func(err error) bool { return err != errFatal }}, errFatal, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.policy.Clock = &fakeClock{}
+			step, calls := failing(10, test.err)
+
+			err := RetryContext(test.policy, step)(context.Background())
+			var retryError *RetryError
+			if !errors.As(err, &retryError) {
+				t.Fatalf("got %v, want a *RetryError", err)
+			}
+			if *calls != test.attempts || retryError.Attempts != test.attempts || len(retryError.Errs) != test.attempts {
+				t.Errorf("got %d attempts reported as %+v, want %d", *calls, retryError, test.attempts)
+			}
+			if !errors.Is(err, test.err) {
+				t.Errorf("got %v, want it to wrap %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	t.Run("before the first attempt", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		step, calls := failing(0, nil)
+
+		err := RetryContext(RetryPolicy{Clock: &fakeClock{}}, step)(ctx)
+		if err != context.Canceled || *calls != 0 {
+			t.Errorf("got %v after %d attempts, want %v without any", err, *calls, context.Canceled)
+		}
+	})
+
+	t.Run("during the backoff", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		step := func(context.Context) error {
+			cancel()
+			return errFailed
+		}
+
+		err := RetryContext(RetryPolicy{Clock: &fakeClock{blocked: true}}, step)(ctx)
+		var retryError *RetryError
+		if !errors.As(err, &retryError) || retryError.Attempts != 1 {
+			t.Fatalf("got %v, want a *RetryError after one attempt", err)
+		}
+		if !errors.Is(err, errFailed) || !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want both the failure and the cancellation", err)
+		}
+	})
+}
+
+func TestRetryInvalidJitter(t *testing.T) {
+	for _, jitter := range []float64{-0.5, 1.5} {
+		step, calls := failing(0, nil)
+		err := Retry(RetryPolicy{Jitter: jitter}, func() error { return step(context.Background()) })()
+		if !errors.Is(err, ErrInvalidPolicy) || *calls != 0 {
+			t.Errorf("jitter %v: got %v after %d attempts, want %v", jitter, err, *calls, ErrInvalidPolicy)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package errorhandling
+
+import (
+	"errors"
+	"fmt"
+)
+
+// START Compensable OMIT
+type Compensable struct {
+	Do   func() error
+	Undo func() error
+}
+
+// END Compensable OMIT
+
+// START Compensate OMIT
+func Compensate(do, undo func() error) Compensable {
+	return Compensable{Do: do, Undo: undo}
+}
+
+// END Compensate OMIT
+
+// START CompensationError OMIT
+type CompensationError struct {
+	Err      error
+	UndoErrs []error
+}
+
+// END CompensationError OMIT
+
+func (e *CompensationError) Error() string {
+	return fmt.Sprintf("%v; compensation failed: %v", e.Err, errors.Join(e.UndoErrs...))
+}
+
+func (e *CompensationError) Unwrap() []error {
+	return append([]error{e.Err}, e.UndoErrs...)
+}
+
+// START DoTransaction OMIT
+func DoTransaction(steps ...Compensable) error {
+	for n, step := range steps {
+		if err := step.Do(); err != nil {
+			return compensate(err, steps[:n])
+		}
+	}
+	return nil
+}
+
+// END DoTransaction OMIT
+
+// compensate undoes the completed steps in reverse order. It returns err if
+// all of them succeed and a *CompensationError otherwise.
+func compensate(err error, completed []Compensable) error {
+	var undoErrs []error
+	for n := len(completed) - 1; n >= 0; n-- {
+		if completed[n].Undo == nil {
+			continue
+		}
+		if undoErr := completed[n].Undo(); undoErr != nil {
+			undoErrs = append(undoErrs, undoErr)
+		}
+	}
+	if len(undoErrs) == 0 {
+		return err
+	}
+	return &CompensationError{Err: err, UndoErrs: undoErrs}
+}
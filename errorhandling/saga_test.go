@@ -0,0 +1,62 @@
+package errorhandling
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDoTransaction(t *testing.T) {
+	errFailed := errors.New("failed")
+	errUndo := errors.New("undo failed")
+
+	var calls []string
+	step := func(name string, doErr, undoErr error) Compensable {
+		return Compensate(func() error {
+			calls = append(calls, "do "+name)
+			return doErr
+		}, func() error {
+			calls = append(calls, "undo "+name)
+			return undoErr
+		})
+	}
+
+	t.Run("success", func(t *testing.T) {
+		calls = nil
+		if err := DoTransaction(step("a", nil, nil), step("b", nil, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"do a", "do b"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("got %v, want %v", calls, want)
+		}
+	})
+
+	t.Run("compensation order", func(t *testing.T) {
+		calls = nil
+		err := DoTransaction(step("a", nil, nil), Compensable{Do: func() error { return nil }}, step("b", nil, nil), step("c", errFailed, nil), step("d", nil, nil))
+		if err != errFailed {
+			t.Errorf("got %v, want %v", err, errFailed)
+		}
+		if want := []string{"do a", "do b", "do c", "undo b", "undo a"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("got %v, want %v", calls, want)
+		}
+	})
+
+	t.Run("compensation error", func(t *testing.T) {
+		calls = nil
+		err := DoTransaction(step("a", nil, errUndo), step("b", nil, nil), step("c", errFailed, nil))
+		var compensationError *CompensationError
+		if !errors.As(err, &compensationError) {
+			t.Fatalf("got %v, want a *CompensationError", err)
+		}
+		if compensationError.Err != errFailed || !reflect.DeepEqual(compensationError.UndoErrs, []error{errUndo}) {
+			t.Errorf("got %+v, want %v after %v", compensationError, errUndo, errFailed)
+		}
+		if !errors.Is(err, errFailed) || !errors.Is(err, errUndo) {
+			t.Errorf("got %v, want it to wrap both errors", err)
+		}
+		if want := []string{"do a", "do b", "do c", "undo b", "undo a"}; !reflect.DeepEqual(calls, want) {
+			t.Errorf("got %v, want every completed step undone", calls)
+		}
+	})
+}
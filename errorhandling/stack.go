@@ -0,0 +1,172 @@
+package errorhandling
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+var captureStackTraces atomic.Bool
+
+func init() {
+	captureStackTraces.Store(true)
+}
+
+// CaptureStackTraces turns capturing the stack at every failed check on or
+// off, it is on by default. Only the program counters are recorded, they are
+// resolved to frames when the stack trace is printed.
+func CaptureStackTraces(enabled bool) {
+	captureStackTraces.Store(enabled)
+}
+
+// packagePrefix is the prefix of the functions of this package.
+var packagePrefix = reflect.TypeOf(StackError{}).PkgPath() + "."
+
+// checkFrames are the functions between a check and its caller, which are
+// left out of the captured stacks. Other functions of this package using
+// check keep their frames.
+var checkFrames = map[string]bool{
+	"callers":         true,
+	"stack":           true,
+	"NewError":        true,
+	"Check":           true,
+	"Check0":          true,
+	"Check2":          true,
+	"CheckStep":       true,
+	"(*Scope).Check0": true,
+	"CheckIn.func":    true,
+	"CheckIn2.func":   true,
+}
+
+// isCheckFrame reports whether function is one of checkFrames. Type
+// arguments of generic functions are left out and closures are named
+// .func, whether the runtime numbers them like .func1 or, inlined, like .1.
+func isCheckFrame(function string) bool {
+	name, ok := strings.CutPrefix(function, packagePrefix)
+	if !ok {
+		return false
+	}
+	name = strings.ReplaceAll(name, "[...]", "")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		closure := strings.TrimPrefix(name[i+1:], "func")
+		if _, err := strconv.Atoi(closure); err == nil {
+			name = name[:i] + ".func"
+		}
+	}
+	return checkFrames[name]
+}
+
+// callers returns the stack from the caller of the check, or nil if stack
+// traces are turned off. The frames of the check are counted with inlined
+// functions expanded, runtime.Callers skips them the same way.
+func callers() []uintptr {
+	if !captureStackTraces.Load() {
+		return nil
+	}
+
+	pcs := make([]uintptr, 32)
+	skip := 0
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !isCheckFrame(frame.Function) || !more {
+			break
+		}
+		skip++
+	}
+	return pcs[:runtime.Callers(1+skip, pcs)]
+}
+
+// START StackError OMIT
+type StackError struct {
+	err   error
+	stack []uintptr
+}
+
+// END StackError OMIT
+
+func (e *StackError) Error() string {
+	return e.err.Error()
+}
+
+func (e *StackError) Unwrap() error {
+	return e.err
+}
+
+// StackTrace returns the frames of the check which failed, innermost first.
+func (e *StackError) StackTrace() []runtime.Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+
+	var frames []runtime.Frame
+	iter := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, frame)
+		if !more {
+			return frames
+		}
+	}
+}
+
+// Format prints the stack trace after the message for %+v.
+func (e *StackError) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		io.WriteString(s, e.Error())
+		for _, frame := range e.StackTrace() {
+			fmt.Fprintf(s, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		}
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		io.WriteString(s, e.Error())
+	}
+}
+
+// stack returns the stack of a *StackError wrapped by err, so that checking
+// an error returned by a function using check keeps the innermost stack,
+// and otherwise the stack of the caller.
+func stack(err error) []uintptr {
+	var stackError *StackError
+	if errors.As(err, &stackError) {
+		return stackError.stack
+	}
+	return callers()
+}
+
+// PanicError is a panic of a goroutine of a Group with RecoverPanics,
+// forwarded to Wait. The goroutine has ended by then, so the stack of the
+// original panic is kept in Stack and printed with %+v.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprint(e.Value)
+}
+
+// Unwrap returns Value if it is an error, like an http.ErrAbortHandler.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Format prints the stack of the panic after the value for %+v.
+func (e *PanicError) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		fmt.Fprintf(s, "%s\n\n%s", e.Error(), e.Stack)
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		io.WriteString(s, e.Error())
+	}
+}
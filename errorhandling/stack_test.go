@@ -0,0 +1,53 @@
+package errorhandling
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStackTrace(t *testing.T) {
+	errFailed := errors.New("failed")
+	scope := NewScope()
+	checks := map[string]func() error{
+		"Check": func() (err error) {
+			defer Handle(&err)
+			Check(0, errFailed)
+			return nil
+		},
+		"Check0": func() (err error) {
+			defer Handle(&err)
+			Check0(errFailed)
+			return nil
+		},
+		"Check2": func() (err error) {
+			defer Handle(&err)
+			Check2(0, 0, errFailed)
+			return nil
+		},
+		"CheckStep": func() (err error) {
+			defer Handle(&err)
+			CheckStep("step", errFailed)
+			return nil
+		},
+		"CheckIn": func() (err error) {
+			defer scope.Handle(&err)
+			CheckIn(0, errFailed)(scope)
+			return nil
+		},
+	}
+	for name, check := range checks {
+		t.Run(name, func(t *testing.T) {
+			// The checks are made in this package, only the frames of
+			// check itself are left out.
+			var stackError *StackError
+			if !errors.As(check(), &stackError) {
+				t.Fatal("the stack is not captured")
+			}
+			frames := stackError.StackTrace()
+			if len(frames) == 0 || !strings.HasPrefix(frames[0].Function, packagePrefix+"TestStackTrace.func") {
+				t.Errorf("got %+v, want the caller of the check first", stackError)
+			}
+		})
+	}
+}
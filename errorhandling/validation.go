@@ -0,0 +1,97 @@
+package errorhandling
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// START ValidationChecker OMIT
+type ValidationChecker struct {
+	errs []error
+	wrap func(error) error
+}
+
+// END ValidationChecker OMIT
+
+// START NewValidationChecker OMIT
+func NewValidationChecker() *ValidationChecker {
+	return &ValidationChecker{}
+}
+
+// END NewValidationChecker OMIT
+
+// START ValidationChecker Err OMIT
+func (c *ValidationChecker) Err() error {
+	return errors.Join(c.errs...)
+}
+
+// END ValidationChecker Err OMIT
+
+// START ValidationChecker Errs OMIT
+func (c *ValidationChecker) Errs() []error {
+	return c.errs
+}
+
+// END ValidationChecker Errs OMIT
+
+// START ValidationChecker Step OMIT
+func (c *ValidationChecker) Step(name string) *ValidationChecker {
+	return c.Wrap(func(err error) error {
+		return fmt.Errorf("%s: %w", name, err)
+	})
+}
+
+// END ValidationChecker Step OMIT
+
+// START ValidationChecker Wrap OMIT
+func (c *ValidationChecker) Wrap(wrap func(error) error) *ValidationChecker {
+	c.wrap = wrap
+	return c
+}
+
+// END ValidationChecker Wrap OMIT
+
+// START ValidationChecker record OMIT
+func (c *ValidationChecker) record(err error) {
+	wrap := c.wrap
+	c.wrap = nil
+	if err == nil {
+		return
+	}
+	if wrap != nil {
+		err = wrap(err)
+	}
+	if _, file, line, ok := runtime.Caller(2); ok {
+		err = fmt.Errorf("%s:%d: %w", filepath.Base(file), line, err)
+	}
+	c.errs = append(c.errs, err)
+}
+
+// END ValidationChecker record OMIT
+
+// START ValidationChecker StrconvAtoi OMIT
+func (c *ValidationChecker) StrconvAtoi(s string) int {
+	result, err := strconv.Atoi(s)
+	c.record(err)
+	return result
+}
+
+// END ValidationChecker StrconvAtoi OMIT
+
+// START ValidationChecker JsonUnmarshal OMIT
+func (c *ValidationChecker) JsonUnmarshal(data []byte, v interface{}) {
+	c.record(json.Unmarshal(data, v))
+}
+
+// END ValidationChecker JsonUnmarshal OMIT
+
+// START ValidationChecker Decode OMIT
+func (c *ValidationChecker) Decode(encoding string, data []byte, v interface{}) {
+	c.record(Decode(encoding, data, v))
+}
+
+// END ValidationChecker Decode OMIT
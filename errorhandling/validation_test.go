@@ -0,0 +1,56 @@
+package errorhandling
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestValidationChecker(t *testing.T) {
+	checker := NewValidationChecker()
+	var v struct{ Name string }
+
+	_, _, line, _ := runtime.Caller(0)
+	a := checker.StrconvAtoi("one")
+	b := checker.StrconvAtoi("2")
+	c := checker.Step("third").StrconvAtoi("three")
+	checker.JsonUnmarshal([]byte(`{"name": 4}`), &v)
+	d := checker.StrconvAtoi("x")
+
+	if a != 0 || b != 2 || c != 0 || d != 0 {
+		t.Errorf("got %d %d %d %d, want every valid value and zero for the rest", a, b, c, d)
+	}
+	errs := checker.Errs()
+	want := []string{
+		fmt.Sprintf("validation_test.go:%d: ", line+1),
+		fmt.Sprintf("validation_test.go:%d: third: ", line+3),
+		fmt.Sprintf("validation_test.go:%d: ", line+4),
+		fmt.Sprintf("validation_test.go:%d: ", line+5),
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d failures %v, want %d", len(errs), errs, len(want))
+	}
+	for n, prefix := range want {
+		if got := errs[n].Error(); !strings.HasPrefix(got, prefix) {
+			t.Errorf("failure %d: got %q, want it to start with %q", n, got, prefix)
+		}
+	}
+	if err := checker.Err(); !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("got %v, want it to join the failures", err)
+	}
+}
+
+func TestValidationCheckerValid(t *testing.T) {
+	checker := NewValidationChecker()
+	checker.Step("first").StrconvAtoi("1")
+	if got := checker.StrconvAtoi("x"); got != 0 {
+		t.Errorf("got %d, want 0", got)
+	}
+	// The step of a valid call does not carry over to the next one.
+	if err := checker.Err(); len(checker.Errs()) != 1 || strings.Contains(err.Error(), "first") {
+		t.Errorf("got %v, want only the failure of the second call", err)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build ignore
+
 // Copyright 2015 The etcd Authors
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
@@ -1,3 +1,5 @@
+//go:build ignore
+
 package kubernetes
 
 func (DeploymentV1Beta1) Generate(genericParams map[string]interface{}) (runtime.Object, error) {
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START getCommandsFromFile OMIT
+var getCommands, errGetCommands = errorhandling.Compose[string, []string]( // HL_compose
+	errorhandling.Stage{Name: "read configuration", F: errorhandling.ReadConfiguration},
+	errorhandling.Stage{Name: "parse configuration", F: errorhandling.ParseConfiguration},
+	errorhandling.Stage{Name: "calculate commands", F: errorhandling.CalculateCommands},
+)
+
+func getCommandsFromFile(filename string) ([]string, error) {
+	return errorhandling.StepFunc(filename, getCommands)(filename)
+}
+
+// END getCommandsFromFile OMIT
+
+// START wrongOrder OMIT
+var _, errWrongOrder = errorhandling.Compose[string, []string]( // HL_compose
+	errorhandling.Stage{Name: "read configuration", F: errorhandling.ReadConfiguration},
+	errorhandling.Stage{Name: "calculate commands", F: errorhandling.CalculateCommands},
+	errorhandling.Stage{Name: "parse configuration", F: errorhandling.ParseConfiguration},
+)
+
+// END wrongOrder OMIT
+
+// START main OMIT
+func main() {
+	fmt.Println(errWrongOrder)
+	if errGetCommands != nil {
+		panic(errGetCommands)
+	}
+
+	fmt.Println(getCommandsFromFile("resources/not_enough_lines"))
+	fmt.Println(getCommandsFromFile("resources/version_not_a_number"))
+	fmt.Println(getCommandsFromFile("resources/invalid_json"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_version"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_mode"))
+	fmt.Println(getCommandsFromFile("resources/valid"))
+}
+
+// END main OMIT
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+const readTimeout = 100 * time.Millisecond
+
+// START getCommandsFromFile OMIT
+func getCommandsFromFile(ctx context.Context, filename string, latency time.Duration) ([]string, error) {
+	return errorhandling.As[[]string](errorhandling.DoEitherContext( // HL_context
+		ctx, filename, // HL_context
+		errorhandling.TimeoutEither(readTimeout, errorhandling.EitherWrapContext(readConfiguration(latency))), // HL_context
+		errorhandling.EitherContext(errorhandling.EitherWrap(errorhandling.ParseConfiguration)),               // HL_context
+		errorhandling.EitherContext(errorhandling.EitherWrap(errorhandling.CalculateCommands)),                // HL_context
+	)) // HL_context
+}
+
+// END getCommandsFromFile OMIT
+
+// START readConfiguration OMIT
+func readConfiguration(latency time.Duration) func(context.Context, string) (*errorhandling.RawConfiguration, error) {
+	return func(ctx context.Context, filename string) (*errorhandling.RawConfiguration, error) {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return errorhandling.ReadConfigurationContext(ctx, filename)
+	}
+}
+
+// END readConfiguration OMIT
+
+// START main OMIT
+func main() {
+	report(getCommandsFromFile(context.Background(), "resources/valid", 0))
+	report(getCommandsFromFile(context.Background(), "resources/invalid_json", 0))
+	report(getCommandsFromFile(context.Background(), "resources/valid", time.Second))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report(getCommandsFromFile(ctx, "resources/valid", 0))
+}
+
+func report(commands []string, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		fmt.Println("timeout:", err)
+	case errors.Is(err, context.Canceled):
+		fmt.Println("canceled:", err)
+	case err != nil:
+		fmt.Println("failure:", err)
+	default:
+		fmt.Println(commands)
+	}
+}
+
+// END main OMIT
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+var errMountUnavailable = errors.New("network mount unavailable")
+
+// START policy OMIT
+var policy = errorhandling.RetryPolicy{
+	Attempts: 4,
+	Delay:    10 * time.Millisecond,
+	MaxDelay: 50 * time.Millisecond,
+	Jitter:   0.5,
+	Retryable: func(err error) bool {
+		return errors.Is(err, errMountUnavailable)
+	},
+}
+
+// END policy OMIT
+
+// START getCommandsFromFile OMIT
+func getCommandsFromFile(filename string, failures int) ([]string, error) {
+	mount := &flakyMount{failures: failures}
+	return errorhandling.As[[]string](errorhandling.DoEither( // HL_retry
+		filename, // HL_retry
+		errorhandling.RetryEither(policy, errorhandling.EitherWrap(mount.readConfiguration)), // HL_retry
+		errorhandling.EitherWrap(errorhandling.ParseConfiguration),                           // HL_retry
+		errorhandling.EitherWrap(errorhandling.CalculateCommands),                            // HL_retry
+	)) // HL_retry
+}
+
+// END getCommandsFromFile OMIT
+
+// START flakyMount OMIT
+type flakyMount struct {
+	failures int
+}
+
+func (m *flakyMount) readConfiguration(filename string) (*errorhandling.RawConfiguration, error) {
+	if m.failures > 0 {
+		m.failures--
+		return nil, fmt.Errorf("open %s: %w", filename, errMountUnavailable)
+	}
+	return errorhandling.ReadConfiguration(filename)
+}
+
+// END flakyMount OMIT
+
+// START main OMIT
+func main() {
+	fmt.Println(getCommandsFromFile("resources/valid", 2))
+	fmt.Println(getCommandsFromFile("resources/valid", 5))
+	fmt.Println(getCommandsFromFile("resources/not_enough_lines", 1))
+}
+
+// END main OMIT
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START getCommandsFromFile OMIT
+func getCommandsFromFile(filename string) (commands []string, err error) {
+	defer errorhandling.HandleStep(&err, filename)
+
+	rawConfiguration := errorhandling.Check(errorhandling.StepFunc("read configuration", errorhandling.ReadConfiguration)(filename))
+	configuration := errorhandling.Check(errorhandling.StepFunc("parse configuration", errorhandling.ParseConfiguration)(rawConfiguration))
+	commands = errorhandling.Check(errorhandling.StepFunc("calculate commands", calculateCommands)(configuration))
+	return commands, nil
+}
+
+// END getCommandsFromFile OMIT
+
+// START calculateCommands OMIT
+func calculateCommands(configuration *errorhandling.Configuration) (commands []string, err error) {
+	defer errorhandling.Handle(&err)
+
+	var downCommands, upCommands []string
+	var group errorhandling.Group // HL_group
+	group.Go(func() {             // HL_group
+		downCommands = errorhandling.Check(errorhandling.StepFunc(errorhandling.Down, errorhandling.CalculateDownCommands)(configuration))
+	})
+	group.Go(func() { // HL_group
+		upCommands = errorhandling.Check(errorhandling.StepFunc(errorhandling.Up, errorhandling.CalculateUpCommands)(configuration))
+	})
+	group.Wait() // HL_group
+
+	return append(downCommands, upCommands...), nil
+}
+
+// END calculateCommands OMIT
+
+// START main OMIT
+func main() {
+	fmt.Println(getCommandsFromFile("resources/incorrect_version"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_mode"))
+	fmt.Println(getCommandsFromFile("resources/valid"))
+}
+
+// END main OMIT
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START getCommandsFromFile OMIT
+func getCommandsFromFile(filename string) (commands []string, err error) {
+	defer errorhandling.HandleWith(&err, errorhandling.Annotate(filename)) // HL_handlers
+
+	rawConfiguration := errorhandling.Check(readConfiguration(filename))
+	configuration := errorhandling.Check(parseConfiguration(rawConfiguration))
+	return errorhandling.Check(errorhandling.StepFunc("calculate commands", errorhandling.CalculateCommands)(configuration)), nil
+}
+
+// END getCommandsFromFile OMIT
+
+// START readConfiguration OMIT
+func readConfiguration(filename string) (rawConfiguration *errorhandling.RawConfiguration, err error) {
+	defer errorhandling.HandleWith(&err, errorhandling.Annotate("read configuration")) // HL_handlers
+
+	f := errorhandling.Check(os.Open(filename))
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	header, _, err := reader.ReadLine()
+	errorhandling.CheckStep("header", errorhandling.TooFewLines(err))
+	body, _, err := reader.ReadLine()
+	errorhandling.CheckStep("body", errorhandling.TooFewLines(err))
+
+	return errorhandling.NewRawConfiguration(filename, header, body, reader)
+}
+
+// END readConfiguration OMIT
+
+// START parseConfiguration OMIT
+func parseConfiguration(configuration *errorhandling.RawConfiguration) (parsedConfiguration *errorhandling.Configuration, err error) {
+	defer errorhandling.HandleWith(&err, errorhandling.Annotate("parse configuration")) // HL_handlers
+
+	version := errorhandling.Check(errorhandling.WrapFunc(errorhandling.NewVersionError, strconv.Atoi)(string(configuration.Header)))
+
+	var data map[string]string
+	errorhandling.Check0(errorhandling.NewBodySyntaxError(errorhandling.Decode(configuration.Encoding, configuration.Body, &data)))
+
+	return &errorhandling.Configuration{
+		Version: version,
+		Data:    data,
+	}, nil
+}
+
+// END parseConfiguration OMIT
+
+// START printCommands OMIT
+func printCommands(filename string) (err error) {
+	defer errorhandling.HandleWith(&err, errorhandling.LogAndContinue(func(err error) { // HL_handlers
+		fmt.Println("skipping", err) // HL_handlers
+	})) // HL_handlers
+
+	fmt.Println(errorhandling.Check(getCommandsFromFile(filename)))
+	return nil
+}
+
+// END printCommands OMIT
+
+// START main OMIT
+func main() {
+	for _, filename := range []string{
+		"resources/not_enough_lines",
+		"resources/version_not_a_number",
+		"resources/invalid_json",
+		"resources/incorrect_version",
+		"resources/incorrect_mode",
+		"resources/valid",
+	} {
+		if err := printCommands(filename); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// END main OMIT
@@ -1,143 +1,35 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"os"
-	"strconv"
-)
-
-const (
-	latest = 2
-
-	down = "down"
-	up   = "up"
 
-	dhcp   = "dhcp"
-	static = "static"
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
 )
 
-type RawConfiguration struct {
-	header []byte
-	body   []byte
-}
-
-type Configuration struct {
-	Version int
-	Data    map[string]string
-}
-
-// START Error  OMIT
-type Error struct {
-	err error
-}
-
-// END Error  OMIT
-
-// START NewError OMIT
-func NewError(err error) *Error {
-	return &Error{err: err}
-}
-
-// END NewError OMIT
-
-// START handle OMIT
-func handle(err *error) {
-	if r := recover(); r != nil {
-		if recoveredError, ok := r.(*Error); ok {
-			*err = recoveredError.err
-		} else {
-			panic(r)
-		}
-	}
-}
-
-// END handle OMIT
-
-// START check OMIT
-func check(x interface{}, err error) interface{} {
-	if err != nil {
-		panic(&Error{err: err})
-	}
-	return x
-}
-
-// END check OMIT
-
 // START getCommandsFromFile OMIT
 func getCommandsFromFile(filename string) (commands []string, err error) {
-	defer handle(&err)
+	defer errorhandling.HandleStep(&err, filename)
 
-	rawConfiguration := check(readConfiguration(filename)).(*RawConfiguration)    // HL_check
-	configuration := check(parseConfiguration(rawConfiguration)).(*Configuration) // HL_check
-	commands = check(calculateCommands(configuration)).([]string)                 // HL_check
+	rawConfiguration := errorhandling.Check(errorhandling.StepFunc("read configuration", errorhandling.ReadConfiguration)(filename))        // HL_check
+	configuration := errorhandling.Check(errorhandling.StepFunc("parse configuration", errorhandling.ParseConfiguration)(rawConfiguration)) // HL_check
+	commands = errorhandling.Check(errorhandling.StepFunc("calculate commands", calculateCommands)(configuration))                          // HL_check
 	return commands, nil
 }
 
 // END getCommandsFromFile OMIT
 
-// START readConfiguration OMIT
-func readConfiguration(filename string) (*RawConfiguration, error) {
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
-	reader := bufio.NewReader(f)        // HL_error_in_struct
-	header, _, err := reader.ReadLine() // HL_error_in_struct
-	if err != nil {                     // HL_error_in_struct
-		return nil, err // HL_error_in_struct
-	} // HL_error_in_struct
-	// HL_error_in_struct
-	body, _, err := reader.ReadLine() // HL_error_in_struct
-	if err != nil {                   // HL_error_in_struct
-		return nil, err // HL_error_in_struct
-	} // HL_error_in_struct
-
-	return &RawConfiguration{
-		header: header,
-		body:   body,
-	}, nil
-}
-
-// END readConfiguration OMIT
-
-// START parseConfiguration OMIT
-func parseConfiguration(configuration *RawConfiguration) (*Configuration, error) {
-	version, err := strconv.Atoi(string(configuration.header))
-	if err != nil {
-		return nil, err
-	}
-
-	var data map[string]string
-	err = json.Unmarshal(configuration.body, &data)
-	if err != nil {
-		return nil, err
-	}
-
-	return &Configuration{
-		Version: version,
-		Data:    data,
-	}, nil
-}
-
-// END parseConfiguration OMIT
-
 // START calculateCommands OMIT
-func calculateCommands(configuration *Configuration) ([]string, error) {
+func calculateCommands(configuration *errorhandling.Configuration) ([]string, error) {
 	var commands []string
 	downCommands, err := calculateDownCommands(configuration)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", errorhandling.Down, err)
 	}
 	commands = append(commands, downCommands...)
 
-	upCommands, err := calculateUpCommands(configuration)
+	upCommands, err := errorhandling.CalculateUpCommands(configuration)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", errorhandling.Up, err)
 	}
 	commands = append(commands, upCommands...)
 
@@ -147,36 +39,12 @@ func calculateCommands(configuration *Configuration) ([]string, error) {
 // END calculateCommands OMIT
 
 // START calculateDownCommands OMIT
-func calculateDownCommands(configuration *Configuration) ([]string, error) {
+func calculateDownCommands(configuration *errorhandling.Configuration) ([]string, error) {
 	panic("panic in calculateDownCommands")
 }
 
 // END calculateDownCommands OMIT
 
-// END OMIT
-
-// START calculateUpCommands OMIT
-func calculateUpCommands(configuration *Configuration) ([]string, error) {
-	switch configuration.Data[up] {
-	case dhcp:
-		if configuration.Version < latest {
-			return nil, errors.New("DHCP not supported")
-		}
-		return []string{
-			"ifup eth0",
-			"dhclient",
-		}, nil
-	case static:
-		return []string{
-			"ifdown eth0",
-		}, nil
-	default:
-		return nil, errors.New("unsupported configuration mode")
-	}
-}
-
-// END calculateUpCommands OMIT
-
 // START main OMIT
 func main() {
 	fmt.Println(getCommandsFromFile("resources/valid"))
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START readConfiguration OMIT
+func readConfiguration(filename string) (rawConfiguration *errorhandling.RawConfiguration, err error) {
+	scope := errorhandling.NewScope()                       // HL_scope
+	defer scope.HandleWith(&err, errorhandling.TooFewLines) // HL_scope
+
+	f := errorhandling.CheckIn(os.Open(filename))(scope) // HL_scope
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	header := readLine(scope, reader) // HL_scope
+	body := readLine(scope, reader)   // HL_scope
+	return errorhandling.NewRawConfiguration(filename, header, body, reader)
+}
+
+// readLine leaves the error to the handle of the scope it is given.
+func readLine(scope *errorhandling.Scope, reader *bufio.Reader) []byte {
+	line, _ := errorhandling.CheckIn2(reader.ReadLine())(scope) // HL_scope
+	return line
+}
+
+// END readConfiguration OMIT
+
+// START main OMIT
+func main() {
+	for _, filename := range []string{"resources/not_enough_lines", "resources/missing", "resources/valid"} {
+		rawConfiguration, err := readConfiguration(filename)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%s %s\n", rawConfiguration.Header, rawConfiguration.Body)
+	}
+}
+
+// END main OMIT
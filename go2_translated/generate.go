@@ -0,0 +1,3 @@
+package main
+
+//go:generate go run github.com/jkmar/go_less_verbose_error_handling/cmd/go2check -o main.go main.go2
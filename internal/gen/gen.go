@@ -0,0 +1,261 @@
+// Package gen contains the pieces shared by the code generators in cmd:
+// loading packages with go/types, tracking imports of the generated file,
+// printing types and zero values and formatting the output.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const Header = "// Code generated by %s; DO NOT EDIT.\n\n"
+
+func LoadPackage(path string) (*types.Package, error) {
+	pkg, err := importer.ForCompiler(token.NewFileSet(), "source", nil).Import(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading package %q: %w", path, err)
+	}
+	return pkg, nil
+}
+
+// Imports assigns a unique name to every package referenced by the
+// generated code.
+type Imports struct {
+	self   string
+	names  map[string]string
+	byName map[string]string
+}
+
+func NewImports(self string) *Imports {
+	return &Imports{
+		self:   self,
+		names:  make(map[string]string),
+		byName: make(map[string]string),
+	}
+}
+
+func (i *Imports) Name(pkg *types.Package) string {
+	if pkg.Path() == i.self {
+		return ""
+	}
+	if name, ok := i.names[pkg.Path()]; ok {
+		return name
+	}
+
+	name := pkg.Name()
+	for n := 2; i.byName[name] != ""; n++ {
+		name = pkg.Name() + strconv.Itoa(n)
+	}
+	i.names[pkg.Path()] = name
+	i.byName[name] = pkg.Path()
+	return name
+}
+
+func (i *Imports) Qualifier(pkg *types.Package) string {
+	return i.Name(pkg)
+}
+
+func (i *Imports) Used(name string) bool {
+	return i.byName[name] != ""
+}
+
+func (i *Imports) TypeString(t types.Type) string {
+	return types.TypeString(t, i.Qualifier)
+}
+
+func (i *Imports) Write(buf *bytes.Buffer) {
+	if len(i.names) == 0 {
+		return
+	}
+
+	paths := make([]string, 0, len(i.names))
+	for path := range i.names {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	buf.WriteString("import (\n")
+	for _, path := range paths {
+		name := i.names[path]
+		if name == lastElement(path) {
+			fmt.Fprintf(buf, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(buf, "\t%s %q\n", name, path)
+		}
+	}
+	buf.WriteString(")\n\n")
+}
+
+func lastElement(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// Zero returns an expression evaluating to the zero value of t.
+func (i *Imports) Zero(t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		default:
+			return "nil"
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil"
+	default:
+		return i.TypeString(t) + "{}"
+	}
+}
+
+// Exported reports whether the object can be referenced from the
+// generated package.
+func Exported(obj types.Object) bool {
+	return obj.Exported() && obj.Pkg() != nil
+}
+
+// ReturnsError reports whether the last result of the signature is error.
+func ReturnsError(sig *types.Signature) bool {
+	results := sig.Results()
+	if results.Len() == 0 {
+		return false
+	}
+	return types.Identical(results.At(results.Len()-1).Type(), types.Universe.Lookup("error").Type())
+}
+
+// ParamNames returns names for the parameters of sig that are valid,
+// unique and do not shadow any of the reserved names.
+func ParamNames(sig *types.Signature, reserved func(string) bool) []string {
+	params := sig.Params()
+	names := make([]string, params.Len())
+	used := make(map[string]bool)
+	for n := 0; n < params.Len(); n++ {
+		name := params.At(n).Name()
+		if name == "" || name == "_" {
+			name = "p" + strconv.Itoa(n)
+		}
+		for used[name] || reserved(name) || token.IsKeyword(name) {
+			name += "_"
+		}
+		used[name] = true
+		names[n] = name
+	}
+	return names
+}
+
+// Params returns the parameter list of sig using the given names.
+func (i *Imports) Params(sig *types.Signature, names []string) string {
+	params := sig.Params()
+	list := make([]string, params.Len())
+	for n := 0; n < params.Len(); n++ {
+		t := params.At(n).Type()
+		if sig.Variadic() && n == params.Len()-1 {
+			list[n] = names[n] + " ..." + i.TypeString(t.(*types.Slice).Elem())
+		} else {
+			list[n] = names[n] + " " + i.TypeString(t)
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+// Args returns the argument list forwarding the given parameter names.
+func Args(sig *types.Signature, names []string) string {
+	args := strings.Join(names, ", ")
+	if sig.Variadic() {
+		args += "..."
+	}
+	return args
+}
+
+// Results returns the result types of sig without the trailing error.
+func (i *Imports) Results(sig *types.Signature) []types.Type {
+	results := sig.Results()
+	count := results.Len()
+	if ReturnsError(sig) {
+		count--
+	}
+	list := make([]types.Type, 0, count)
+	for n := 0; n < count; n++ {
+		list = append(list, results.At(n).Type())
+	}
+	return list
+}
+
+func (i *Imports) ResultList(results []types.Type) string {
+	list := make([]string, len(results))
+	for n, t := range results {
+		list[n] = i.TypeString(t)
+	}
+	switch len(list) {
+	case 0:
+		return ""
+	case 1:
+		return list[0]
+	default:
+		return "(" + strings.Join(list, ", ") + ")"
+	}
+}
+
+func (i *Imports) Zeros(results []types.Type) string {
+	list := make([]string, len(results))
+	for n, t := range results {
+		list[n] = i.Zero(t)
+	}
+	return strings.Join(list, ", ")
+}
+
+// Source assembles and formats the generated file.
+func Source(generator, pkg string, imports *Imports, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, Header, generator)
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	imports.Write(&buf)
+	buf.Write(body)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// LookupType resolves a type reference of the form [*]importpath.Name,
+// for example *bufio.Reader or io.Writer.
+func LookupType(ref string) (*types.Package, types.Type, error) {
+	pointer := strings.HasPrefix(ref, "*")
+	ref = strings.TrimPrefix(ref, "*")
+
+	dot := strings.LastIndex(ref, ".")
+	if dot <= 0 || dot == len(ref)-1 {
+		return nil, nil, fmt.Errorf("invalid type %q, expected [*]importpath.Name", ref)
+	}
+	path, name := ref[:dot], ref[dot+1:]
+
+	pkg, err := LoadPackage(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok || !obj.Exported() {
+		return nil, nil, fmt.Errorf("package %q has no exported type %s", path, name)
+	}
+	if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		return nil, nil, fmt.Errorf("generic type %s.%s is not supported", path, name)
+	}
+
+	var typ types.Type = obj.Type()
+	if pointer {
+		typ = types.NewPointer(typ)
+	}
+	return pkg, typ, nil
+}
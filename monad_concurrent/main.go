@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START getCommandsFromFile OMIT
+func getCommandsFromFile(filename string) ([]string, error) {
+	rawConfiguration, err := errorhandling.ReadConfiguration(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: read configuration: %w", filename, err)
+	}
+
+	configuration, err := errorhandling.ParseConfiguration(rawConfiguration)
+	if err != nil {
+		return nil, fmt.Errorf("%s: parse configuration: %w", filename, err)
+	}
+
+	commands, err := calculateCommands(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("%s: calculate commands: %w", filename, err)
+	}
+
+	return commands, nil
+}
+
+// END getCommandsFromFile OMIT
+
+// START calculateCommands OMIT
+func calculateCommands(configuration *errorhandling.Configuration) ([]string, error) {
+	calculator := errorhandling.NewConfigurationCalculator(configuration) // HL_monad
+	if err := errorhandling.DoConcurrent(context.Background(),            // HL_monad
+		errorhandling.NoContext(errorhandling.Step(errorhandling.Down, calculator.CalculateDownCommands)), // HL_monad
+		errorhandling.NoContext(errorhandling.Step(errorhandling.Up, calculator.CalculateUpCommands)),     // HL_monad
+	); err != nil { // HL_monad
+		return nil, err // HL_monad
+	} // HL_monad
+
+	return calculator.GetCommands(), nil
+}
+
+// END calculateCommands OMIT
+
+// START main OMIT
+func main() {
+	fmt.Println(getCommandsFromFile("resources/not_enough_lines"))
+	fmt.Println(getCommandsFromFile("resources/version_not_a_number"))
+	fmt.Println(getCommandsFromFile("resources/invalid_json"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_version"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_mode"))
+	fmt.Println(getCommandsFromFile("resources/valid"))
+}
+
+// END main OMIT
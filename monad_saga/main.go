@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START network OMIT
+type network struct {
+	executed []string
+	locked   bool
+}
+
+func (n *network) run(commands ...string) error {
+	for _, command := range commands {
+		if n.locked && strings.HasPrefix(command, "ifup") {
+			return errors.New("eth0 is locked")
+		}
+		n.executed = append(n.executed, command)
+	}
+	return nil
+}
+
+// END network OMIT
+
+// START applyConfiguration OMIT
+func applyConfiguration(n *network, filename string) error {
+	rawConfiguration, err := errorhandling.ReadConfiguration(filename)
+	if err != nil {
+		return fmt.Errorf("%s: read configuration: %w", filename, err)
+	}
+
+	configuration, err := errorhandling.ParseConfiguration(rawConfiguration)
+	if err != nil {
+		return fmt.Errorf("%s: parse configuration: %w", filename, err)
+	}
+
+	if err := errorhandling.DoTransaction( // HL_saga
+		errorhandling.Compensate( // HL_saga
+			errorhandling.Step(errorhandling.Down, func() error { // HL_saga
+				commands, err := errorhandling.CalculateDownCommands(configuration)
+				if err != nil {
+					return err
+				}
+				return n.run(commands...)
+			}),
+			func() error { return n.run("ifup eth0") }, // HL_saga
+		),
+		errorhandling.Compensate( // HL_saga
+			errorhandling.Step(errorhandling.Up, func() error { // HL_saga
+				commands, err := errorhandling.CalculateUpCommands(configuration)
+				if err != nil {
+					return err
+				}
+				return n.run(commands...)
+			}),
+			func() error { return n.run("ifdown eth0") }, // HL_saga
+		),
+	); err != nil { // HL_saga
+		return fmt.Errorf("%s: apply configuration: %w", filename, err) // HL_saga
+	} // HL_saga
+	return nil
+}
+
+// END applyConfiguration OMIT
+
+// START main OMIT
+func main() {
+	for _, n := range []*network{{}, {locked: true}} {
+		for _, filename := range []string{"resources/valid", "resources/incorrect_version"} {
+			n.executed = nil
+			err := applyConfiguration(n, filename)
+			fmt.Println(n.executed, err)
+		}
+	}
+}
+
+// END main OMIT
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START getCommandsFromFile OMIT
+func getCommandsFromFile(filename string) ([]string, error) {
+	return errorhandling.StepFunc(filename, errorhandling.Pipe3( // HL_result
+		errorhandling.StepFunc("read configuration", errorhandling.ReadConfiguration),   // HL_result
+		errorhandling.StepFunc("parse configuration", errorhandling.ParseConfiguration), // HL_result
+		errorhandling.StepFunc("calculate commands", errorhandling.CalculateCommands),   // HL_result
+	))(filename) // HL_result
+}
+
+// END getCommandsFromFile OMIT
+
+// START main OMIT
+func main() {
+	fmt.Println(getCommandsFromFile("resources/not_enough_lines"))
+	fmt.Println(getCommandsFromFile("resources/version_not_a_number"))
+	fmt.Println(getCommandsFromFile("resources/invalid_json"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_version"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_mode"))
+	fmt.Println(getCommandsFromFile("resources/valid"))
+}
+
+// END main OMIT
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jkmar/go_less_verbose_error_handling/errorhandling"
+)
+
+// START getCommandsFromFile OMIT
+func getCommandsFromFile(filename string) ([]string, error) {
+	rawConfiguration, err := errorhandling.ReadConfiguration(filename)
+	if err != nil {
+		return nil, fmt.Errorf("%s: read configuration: %w", filename, err)
+	}
+
+	configuration, err := parseConfiguration(rawConfiguration)
+	if err != nil {
+		return nil, fmt.Errorf("%s: parse configuration: %w", filename, err)
+	}
+
+	commands, err := errorhandling.CalculateCommands(configuration)
+	if err != nil {
+		return nil, fmt.Errorf("%s: calculate commands: %w", filename, err)
+	}
+
+	return commands, nil
+}
+
+// END getCommandsFromFile OMIT
+
+// START parseConfiguration OMIT
+func parseConfiguration(configuration *errorhandling.RawConfiguration) (*errorhandling.Configuration, error) {
+	checker := errorhandling.NewValidationChecker()                                                  // HL_validation
+	version := checker.Wrap(errorhandling.NewVersionError).StrconvAtoi(string(configuration.Header)) // HL_validation
+
+	var data map[string]string
+	checker.Wrap(errorhandling.NewBodySyntaxError).Decode(configuration.Encoding, configuration.Body, &data) // HL_validation
+	if err := checker.Err(); err != nil {                                                                    // HL_validation
+		return nil, err // HL_validation
+	} // HL_validation
+
+	return &errorhandling.Configuration{
+		Version: version,
+		Data:    data,
+	}, nil
+}
+
+// END parseConfiguration OMIT
+
+// START main OMIT
+func main() {
+	fmt.Println(getCommandsFromFile("resources/not_enough_lines"))
+	fmt.Println(getCommandsFromFile("resources/version_not_a_number"))
+	fmt.Println(getCommandsFromFile("resources/invalid_json"))
+	fmt.Println(getCommandsFromFile("resources/invalid_version_and_json"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_version"))
+	fmt.Println(getCommandsFromFile("resources/incorrect_mode"))
+	fmt.Println(getCommandsFromFile("resources/valid"))
+}
+
+// END main OMIT